@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+// configCommands are the subcommands of "config", each returning the process exit code
+var configCommands = map[string]func(args []string) int{
+	"check":        configCheck,
+	"print":        configPrint,
+	"sources":      configSources,
+	"env-template": configEnvTemplate,
+}
+
+// runConfigCommand handles "config <subcommand>" and returns the process exit code
+func runConfigCommand(args []string) int {
+	if len(args) == 0 {
+		configUsage()
+		return 2
+	}
+	cmd, ok := configCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown config command %q\n", args[0])
+		configUsage()
+		return 2
+	}
+	return cmd(args[1:])
+}
+
+func configUsage() {
+	names := make([]string, 0, len(configCommands))
+	for name := range configCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: htmx_quickstart config <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
+	}
+}
+
+// newConfigFlagSet creates a flag set for a subcommand that loads
+// configuration, accepting the same setting flags as the server
+func newConfigFlagSet(name string) (*flag.FlagSet, *config.Flags) {
+	fs := flag.NewFlagSet("htmx_quickstart config "+name, flag.ContinueOnError)
+	return fs, config.RegisterFlags(fs)
+}
+
+// parseConfigFlags parses a subcommand's flags, rejecting positional
+// arguments such as "config check production" that would otherwise be ignored
+func parseConfigFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected argument %q", fs.Arg(0))
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+	return nil
+}
+
+// configCheck loads and validates the configuration for ENV, reporting every problem
+func configCheck(args []string) int {
+	fs, flags := newConfigFlagSet("check")
+	if err := parseConfigFlags(fs, args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(flags.Options())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("configuration is valid for %s\n", cfg.Environment())
+	if cfg.ConfigFile() != "" {
+		fmt.Printf("config file: %s\n", cfg.ConfigFile())
+	}
+	return 0
+}
+
+// configPrint writes the effective configuration with secrets redacted
+func configPrint(args []string) int {
+	fs, flags := newConfigFlagSet("print")
+	format := fs.String("format", "json", "output format: json or env")
+	if err := parseConfigFlags(fs, args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(flags.Options())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch *format {
+	case "json":
+		err = cfg.WriteJSON(os.Stdout)
+	case "env":
+		err = cfg.WriteEnv(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "invalid format %q, must be one of: json, env\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// configSources prints each setting with the layer that supplied it
+func configSources(args []string) int {
+	fs, flags := newConfigFlagSet("sources")
+	if err := parseConfigFlags(fs, args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(flags.Options())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.WriteSources(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// configEnvTemplate writes a .env template generated from the Config definition
+func configEnvTemplate(args []string) int {
+	fs := flag.NewFlagSet("config env-template", flag.ContinueOnError)
+	env := fs.String("env", string(config.EnvDevelopment), "environment whose defaults are filled in")
+	output := fs.String("o", "", "write to this file instead of stdout, e.g. .env.example")
+	if err := parseConfigFlags(fs, args); err != nil {
+		return 2
+	}
+	if !config.Environment(*env).IsBuiltin() {
+		fmt.Fprintf(os.Stderr, "invalid environment %q, must be one of: development, staging, production\n", *env)
+		return 2
+	}
+
+	if *output == "" {
+		if err := config.WriteEnvTemplate(os.Stdout, config.Environment(*env)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	err = config.WriteEnvTemplate(f, config.Environment(*env))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
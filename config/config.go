@@ -1,58 +1,116 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
-	"strconv"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
-// Config holds all configuration for the application
+// Config holds all configuration for the application.
+//
+// Each setting is declared once through struct tags:
+//   - env: environment variable name
+//   - flag: command-line flag name, defaulting to the env name in lower case with dashes
+//   - default: value in every environment unless overridden
+//   - development, staging, production: per-environment default overrides
+//   - required: "true", or a comma-separated list of environments where it must be set
+//   - reload: "true" if a running server may apply a new value on SIGHUP
+//   - secret: "true" if the value must never be logged; the setting can also be
+//     read from the file named by <env>_FILE, e.g. DB_PASSWORD_FILE
+//   - desc: human-readable description
 type Config struct {
 	Server   ServerConfig
 	Session  SessionConfig
 	Logging  LoggingConfig
 	Database DatabaseConfig
+
+	environment Environment       // Active environment: built-in, or a profile from the config file
+	base        Environment       // Built-in environment whose defaults and rules apply
+	sources     map[string]Source // Layer that supplied each non-default value, by env name
+	configFile  string
+}
+
+var (
+	dotEnvMu   sync.Mutex
+	processEnv map[string]bool // Variables set when the process started
+	dotEnvKeys map[string]bool // Variables currently supplied by .env
+)
+
+// Options controls where Load reads configuration from
+type Options struct {
+	// ConfigFile is an optional JSON or TOML file layered above the defaults
+	// and below .env and the process environment. CONFIG_FILE is used when empty.
+	ConfigFile string
+
+	// Environment selects the environment instead of ENV when not empty
+	Environment Environment
+
+	// Overrides are raw setting values by env name, e.g. from command-line
+	// flags, applied above every other layer
+	Overrides map[string]string
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Host            string
-	Port            int
-	Address         string
-	ReadTimeout     time.Duration
-	WriteTimeout    time.Duration
-	ShutdownTimeout time.Duration
+	Host            string        `env:"SERVER_HOST" flag:"host" default:"localhost" staging:"0.0.0.0" production:"0.0.0.0" desc:"Interface to listen on"`
+	Port            int           `env:"SERVER_PORT" flag:"port" default:"9779" staging:"8080" production:"8080" desc:"Port to listen on"`
+	Address         string        `env:"SERVER_ADDRESS" default:"http://localhost" staging:"https://staging.example.com" production:"https://api.example.com" desc:"Public base URL of the application"`
+	ReadTimeout     time.Duration `env:"SERVER_READ_TIMEOUT" default:"30s" desc:"Maximum duration for reading a request"`
+	WriteTimeout    time.Duration `env:"SERVER_WRITE_TIMEOUT" default:"30s" desc:"Maximum duration for writing a response"`
+	ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s" desc:"Grace period for in-flight requests on shutdown"`
 }
 
 // SessionConfig holds session-related configuration
 type SessionConfig struct {
-	CookieName      string
-	MaxAge          time.Duration
-	Secure          bool
-	HttpOnly        bool
-	SameSite        string
-	CleanupInterval time.Duration
+	CookieName      string        `env:"SESSION_COOKIE_NAME" default:"session_id" desc:"Name of the session cookie"`
+	MaxAge          time.Duration `env:"SESSION_MAX_AGE" default:"24h" reload:"true" desc:"Idle timeout, sliding with each request"`
+	AbsoluteTimeout time.Duration `env:"SESSION_ABSOLUTE_TIMEOUT" default:"168h" reload:"true" desc:"Maximum session lifetime from creation regardless of activity, 0 to disable"`
+	Secure          bool          `env:"SESSION_SECURE" default:"false" staging:"true" production:"true" reload:"true" desc:"Only send the session cookie over HTTPS"`
+	HttpOnly        bool          `env:"SESSION_HTTP_ONLY" default:"true" reload:"true" desc:"Hide the session cookie from JavaScript"`
+	SameSite        string        `env:"SESSION_SAME_SITE" default:"lax" staging:"strict" production:"strict" reload:"true" desc:"SameSite cookie mode: lax, strict or none (none requires secure)"`
+	Domain          string        `env:"SESSION_DOMAIN" default:"" reload:"true" desc:"Cookie domain, empty for the current host only"`
+	Path            string        `env:"SESSION_PATH" default:"/" reload:"true" desc:"Cookie path"`
+	HostPrefix      bool          `env:"SESSION_HOST_PREFIX" default:"false" desc:"Prefix the cookie name with __Host- (requires secure, path / and no domain)"`
+	CleanupInterval time.Duration `env:"SESSION_CLEANUP_INTERVAL" default:"1h" staging:"30m" production:"15m" desc:"How often expired sessions are swept"`
+	SecretKeys      []Secret      `env:"SESSION_SECRET_KEYS" development:"insecure-development-session-key-do-not-use" required:"staging,production" secret:"true" desc:"Comma-separated cookie signing keys (32+ characters), newest first; older keys only verify"`
+	Store           string        `env:"SESSION_STORE" default:"memory" desc:"Session backend: memory (server-side) or cookie (stateless, encrypted)"`
+	CookieMaxSize   int           `env:"SESSION_COOKIE_MAX_SIZE" default:"16384" desc:"Maximum encoded size in bytes of a cookie-backed session"`
+	SnapshotFile    string        `env:"SESSION_SNAPSHOT_FILE" development:"tmp/sessions.json" desc:"File memory sessions are saved to on shutdown and restored from on boot, empty to disable"`
+	MaxSessions     int           `env:"SESSION_MAX_SESSIONS" default:"100000" development:"0" desc:"Cap on in-memory sessions, least recently used are evicted; 0 for unlimited"`
+	LazyCreate      bool          `env:"SESSION_LAZY_CREATE" default:"true" development:"false" desc:"Only store a new session and issue its cookie once data is written to it"`
 }
 
 // LoggingConfig holds logging-related configuration
 type LoggingConfig struct {
-	Level  string
-	Format string
+	Level  string `env:"LOG_LEVEL" default:"info" development:"debug" reload:"true" desc:"Log level: debug, info, warn or error"`
+	Format string `env:"LOG_FORMAT" default:"json" development:"text" reload:"true" desc:"Log format: json or text"`
 }
 
 // DatabaseConfig holds database-related configuration
 type DatabaseConfig struct {
-	Driver   string
-	Host     string
-	Port     int
-	Name     string
-	User     string
-	Password string
-	SSLMode  string
+	Driver   string            `env:"DB_DRIVER" default:"postgres" development:"sqlite3" desc:"Database driver: sqlite3, postgres or mysql"`
+	Host     string            `env:"DB_HOST" default:"localhost" staging:"staging-db.example.com" production:"prod-db.example.com" desc:"Database host"`
+	Port     int               `env:"DB_PORT" default:"5432" desc:"Database port"`
+	Name     string            `env:"DB_NAME" default:"app_dev.db" staging:"app_staging" production:"app_prod" desc:"Database name, or file path for sqlite3"`
+	User     string            `env:"DB_USER" default:"app_user" development:"" desc:"Database user"`
+	Password Secret            `env:"DB_PASSWORD" default:"" secret:"true" desc:"Database password"`
+	SSLMode  string            `env:"DB_SSL_MODE" default:"require" development:"disable" desc:"Database SSL mode: disable, allow, prefer, require, verify-ca or verify-full"`
+	Options  map[string]string `env:"DB_OPTIONS" desc:"Extra driver connection parameters as comma-separated key=value pairs, overriding the built-in ones"`
+
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"25" development:"5" desc:"Maximum open connections in the pool, 0 for unlimited"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"5" desc:"Maximum idle connections kept in the pool"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m" desc:"Maximum time a connection is reused, 0 for no limit"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m" desc:"Maximum time a connection stays idle before it is closed, 0 for no limit"`
+	ConnectTimeout  time.Duration `env:"DB_CONNECT_TIMEOUT" default:"10s" desc:"How long to wait for the database on startup"`
+	MigrateOnStart  bool          `env:"DB_MIGRATE_ON_START" default:"false" development:"true" desc:"Apply pending migrations when the server starts"`
 }
 
 // Environment represents the deployment environment
@@ -64,276 +122,412 @@ const (
 	EnvProduction  Environment = "production"
 )
 
-// GetEnvironment returns the current environment
+// GetEnvironment returns the environment named by ENV, development when unset.
+// It may name a profile from the config file; Load rejects unknown names.
 func GetEnvironment() Environment {
-	env := getEnv("ENV", string(EnvDevelopment))
-	switch env {
-	case string(EnvStaging):
-		return EnvStaging
-	case string(EnvProduction):
-		return EnvProduction
-	default:
-		return EnvDevelopment
-	}
+	return Environment(getEnv("ENV", string(EnvDevelopment)))
 }
 
 // Load loads configuration from environment variables with environment-specific defaults
 func Load() (*Config, error) {
-	// Load .env file if it exists (ignore error if file doesn't exist)
-	_ = godotenv.Load()
+	return LoadWithOptions(Options{})
+}
+
+// LoadWithOptions loads configuration in layers of increasing precedence:
+// environment defaults, config file, .env file, process environment, overrides
+func LoadWithOptions(opts Options) (*Config, error) {
+	// Merge .env into the environment, remembering which variables the process set itself
+	processEnv := loadDotEnv()
 
 	env := GetEnvironment()
+	if opts.Environment != "" {
+		env = opts.Environment
+	}
+
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
+	fileValues := make(map[string]string)
+	if configFile != "" {
+		var err error
+		if fileValues, err = readConfigFile(configFile); err != nil {
+			return nil, fmt.Errorf("invalid config file:\n%w", err)
+		}
+	}
+	profiles, err := splitProfiles(fileValues)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file:\n%s: %w", configFile, err)
+	}
+	if err := checkProfiles(configFile, profiles); err != nil {
+		return nil, fmt.Errorf("invalid config file:\n%w", err)
+	}
 
-	cfg := &Config{}
+	// Custom environments inherit the defaults of the built-in one they are based on
+	base, chain, err := resolveEnvironment(env, profiles)
+	if err != nil {
+		return nil, err
+	}
 
 	// Load environment-specific defaults
-	switch env {
-	case EnvDevelopment:
-		cfg = getDevelopmentDefaults()
-	case EnvStaging:
-		cfg = getStagingDefaults()
-	case EnvProduction:
-		cfg = getProductionDefaults()
-	default:
-		cfg = getDevelopmentDefaults()
+	cfg, err := Defaults(base)
+	if err != nil {
+		return nil, err
+	}
+	cfg.environment = env
+	cfg.configFile = configFile
+
+	// Override with the config file, then the active environment's profiles
+	fileErrs := []error{loadFromFile(cfg, configFile, "", fileValues)}
+	for _, name := range chain {
+		fileErrs = append(fileErrs, loadFromFile(cfg, configFile, profilesKey+"."+string(name)+".", profiles[name].values))
+	}
+	if err := errors.Join(fileErrs...); err != nil {
+		return nil, fmt.Errorf("invalid config file:\n%w", err)
 	}
 
 	// Override with environment variables
-	loadFromEnv(cfg)
+	if err := loadFromEnv(cfg, processEnv); err != nil {
+		return nil, fmt.Errorf("invalid environment variables:\n%w", err)
+	}
+
+	// Override with command-line flags
+	if err := loadOverrides(cfg, opts.Overrides); err != nil {
+		return nil, fmt.Errorf("invalid command-line flags:\n%w", err)
+	}
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	// Validate configuration, reporting missing and invalid settings together
+	if err := errors.Join(checkRequired(cfg, base), cfg.Validate()); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
 	}
 
 	return cfg, nil
 }
 
-// getDevelopmentDefaults returns development environment defaults
-func getDevelopmentDefaults() *Config {
-	return &Config{
-		Server: ServerConfig{
-			Host:            "localhost",
-			Port:            9779,
-			Address:         "http://localhost",
-			ReadTimeout:     30 * time.Second,
-			WriteTimeout:    30 * time.Second,
-			ShutdownTimeout: 30 * time.Second,
-		},
-		Session: SessionConfig{
-			CookieName:      "session_id",
-			MaxAge:          24 * time.Hour,
-			Secure:          false, // No HTTPS in development
-			HttpOnly:        true,
-			SameSite:        "lax",
-			CleanupInterval: 1 * time.Hour,
-		},
-		Logging: LoggingConfig{
-			Level:  "debug",
-			Format: "text", // Human-readable in development
-		},
-		Database: DatabaseConfig{
-			Driver:   "sqlite3",
-			Host:     "localhost",
-			Port:     5432,
-			Name:     "app_dev.db",
-			User:     "",
-			Password: "",
-			SSLMode:  "disable",
-		},
+// loadDotEnv merges the .env file, if any, into the process environment
+// without overriding variables the process was started with, and returns
+// those variables. Later calls pick up values changed or removed in .env
+// since the previous load, so a reload sees the file's current contents.
+func loadDotEnv() map[string]bool {
+	dotEnvMu.Lock()
+	defer dotEnvMu.Unlock()
+
+	if processEnv == nil {
+		processEnv = make(map[string]bool)
+		for _, kv := range os.Environ() {
+			key, _, _ := strings.Cut(kv, "=")
+			processEnv[key] = true
+		}
 	}
-}
 
-// getStagingDefaults returns staging environment defaults
-func getStagingDefaults() *Config {
-	return &Config{
-		Server: ServerConfig{
-			Host:            "0.0.0.0",
-			Port:            8080,
-			Address:         "https://staging.example.com",
-			ReadTimeout:     30 * time.Second,
-			WriteTimeout:    30 * time.Second,
-			ShutdownTimeout: 30 * time.Second,
-		},
-		Session: SessionConfig{
-			CookieName:      "session_id",
-			MaxAge:          24 * time.Hour,
-			Secure:          true, // HTTPS in staging
-			HttpOnly:        true,
-			SameSite:        "strict",
-			CleanupInterval: 30 * time.Minute,
-		},
-		Logging: LoggingConfig{
-			Level:  "info",
-			Format: "json",
-		},
-		Database: DatabaseConfig{
-			Driver:   "postgres",
-			Host:     "staging-db.example.com",
-			Port:     5432,
-			Name:     "app_staging",
-			User:     "app_user",
-			Password: "",
-			SSLMode:  "require",
-		},
+	values, _ := godotenv.Read() // A missing .env file is not an error
+	for key := range dotEnvKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+		}
 	}
-}
-
-// getProductionDefaults returns production environment defaults
-func getProductionDefaults() *Config {
-	return &Config{
-		Server: ServerConfig{
-			Host:            "0.0.0.0",
-			Port:            8080,
-			Address:         "https://api.example.com",
-			ReadTimeout:     30 * time.Second,
-			WriteTimeout:    30 * time.Second,
-			ShutdownTimeout: 30 * time.Second,
-		},
-		Session: SessionConfig{
-			CookieName:      "session_id",
-			MaxAge:          24 * time.Hour,
-			Secure:          true, // HTTPS in production
-			HttpOnly:        true,
-			SameSite:        "strict",
-			CleanupInterval: 15 * time.Minute,
-		},
-		Logging: LoggingConfig{
-			Level:  "info",
-			Format: "json",
-		},
-		Database: DatabaseConfig{
-			Driver:   "postgres",
-			Host:     "prod-db.example.com",
-			Port:     5432,
-			Name:     "app_prod",
-			User:     "app_user",
-			Password: "",
-			SSLMode:  "require",
-		},
+	dotEnvKeys = make(map[string]bool, len(values))
+	for key, value := range values {
+		if processEnv[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		dotEnvKeys[key] = true
 	}
+	return processEnv
 }
 
-// loadFromEnv overrides configuration with environment variables
-func loadFromEnv(cfg *Config) {
-	// Server config
-	if v := os.Getenv("SERVER_HOST"); v != "" {
-		cfg.Server.Host = v
-	}
-	if v := os.Getenv("SERVER_PORT"); v != "" {
-		if port, err := strconv.Atoi(v); err == nil {
-			cfg.Server.Port = port
+// Defaults returns the configuration defaults declared on Config for an environment
+func Defaults(env Environment) (*Config, error) {
+	cfg := &Config{environment: env, base: env, sources: make(map[string]Source)}
+	var errs []error
+	for _, s := range Settings(cfg) {
+		value, ok := s.Default(env)
+		if !ok {
+			continue
+		}
+		if err := s.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("default for %s: %w", s.Env, err))
 		}
 	}
-	if v := os.Getenv("SERVER_ADDRESS"); v != "" {
-		cfg.Server.Address = v
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
-	if v := os.Getenv("SERVER_READ_TIMEOUT"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.Server.ReadTimeout = d
+	return cfg, nil
+}
+
+// loadFromEnv overrides configuration with environment variables. Every
+// malformed value is reported in the returned error instead of being ignored.
+// Variables not in processEnv are attributed to the .env file.
+func loadFromEnv(cfg *Config, processEnv map[string]bool) error {
+	var errs []error
+	for _, s := range Settings(cfg) {
+		key := s.Env
+		value, set := os.LookupEnv(key)
+		if s.Secret {
+			fileKey, fileValue, err := readSecretFile(s.Env)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if fileKey != "" {
+				key, value = fileKey, fileValue
+			}
 		}
-	}
-	if v := os.Getenv("SERVER_WRITE_TIMEOUT"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.Server.WriteTimeout = d
+		// An explicitly empty variable clears a string setting; elsewhere,
+		// including the empty secret lines of the env template, it is unset.
+		if !set || value == "" && (s.Secret || s.value.Kind() != reflect.String) {
+			continue
+		}
+		if err := s.Set(value); err != nil {
+			if s.Secret {
+				value = redacted
+			}
+			errs = append(errs, &EnvError{Key: key, Value: value, Err: err})
+			continue
+		}
+		if processEnv[key] {
+			cfg.sources[s.Env] = SourceEnv
+		} else {
+			cfg.sources[s.Env] = SourceDotEnv
 		}
 	}
-	if v := os.Getenv("SERVER_SHUTDOWN_TIMEOUT"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.Server.ShutdownTimeout = d
+	return errors.Join(errs...)
+}
+
+// loadOverrides applies raw values keyed by env name above every other layer
+func loadOverrides(cfg *Config, overrides map[string]string) error {
+	var errs []error
+	for _, s := range Settings(cfg) {
+		value, ok := overrides[s.Env]
+		if !ok {
+			continue
+		}
+		if err := s.Set(value); err != nil {
+			if s.Secret {
+				value = redacted
+			}
+			errs = append(errs, fmt.Errorf("--%s=%q: %w", s.Flag, value, err))
+			continue
 		}
+		cfg.sources[s.Env] = SourceFlag
 	}
+	return errors.Join(errs...)
+}
 
-	// Session config
-	if v := os.Getenv("SESSION_COOKIE_NAME"); v != "" {
-		cfg.Session.CookieName = v
+// readSecretFile reads a secret from the file named by <env>_FILE, as used by
+// Docker and Kubernetes secret mounts. It returns the _FILE variable name and
+// the file contents without the trailing newline, or an empty name when the
+// variable is not set.
+func readSecretFile(env string) (string, string, error) {
+	fileKey := env + "_FILE"
+	path := os.Getenv(fileKey)
+	if path == "" {
+		return "", "", nil
 	}
-	if v := os.Getenv("SESSION_MAX_AGE"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.Session.MaxAge = d
-		}
+	if os.Getenv(env) != "" {
+		return "", "", fmt.Errorf("%s and %s are both set, use only one", env, fileKey)
 	}
-	if v := os.Getenv("SESSION_SECURE"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			cfg.Session.Secure = b
-		}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", &EnvError{Key: fileKey, Value: path, Err: err}
 	}
-	if v := os.Getenv("SESSION_HTTP_ONLY"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			cfg.Session.HttpOnly = b
+	return fileKey, strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Environment returns the environment the configuration was loaded for,
+// which may be a profile defined in the config file
+func (c *Config) Environment() Environment {
+	return c.environment
+}
+
+// BaseEnvironment returns the built-in environment the active environment
+// inherits its defaults and validation rules from
+func (c *Config) BaseEnvironment() Environment {
+	return c.base
+}
+
+// ConfigFile returns the path of the config file that was loaded, if any
+func (c *Config) ConfigFile() string {
+	return c.configFile
+}
+
+// checkRequired reports every setting required in env that has no value
+func checkRequired(cfg *Config, env Environment) error {
+	var errs []error
+	for _, s := range Settings(cfg) {
+		if s.RequiredIn(env) && s.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required in %s", s.Env, env))
 		}
 	}
-	if v := os.Getenv("SESSION_SAME_SITE"); v != "" {
-		cfg.Session.SameSite = v
+	return errors.Join(errs...)
+}
+
+// Validate validates the configuration, reporting every problem found rather
+// than stopping at the first. Some rules depend on the base environment;
+// production and its profiles require secure cookies and a database password.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
 	}
-	if v := os.Getenv("SESSION_CLEANUP_INTERVAL"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.Session.CleanupInterval = d
-		}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("server port must be between 1 and 65535, got %d", c.Server.Port)
 	}
 
-	// Logging config
-	if v := os.Getenv("LOG_LEVEL"); v != "" {
-		cfg.Logging.Level = v
+	if err := validateAddress(c.Server.Address); err != nil {
+		errs = append(errs, err)
 	}
-	if v := os.Getenv("LOG_FORMAT"); v != "" {
-		cfg.Logging.Format = v
+
+	if c.Server.ReadTimeout < 0 {
+		fail("server read timeout must be positive, got %v", c.Server.ReadTimeout)
 	}
 
-	// Database config
-	if v := os.Getenv("DB_DRIVER"); v != "" {
-		cfg.Database.Driver = v
+	if c.Server.WriteTimeout < 0 {
+		fail("server write timeout must be positive, got %v", c.Server.WriteTimeout)
 	}
-	if v := os.Getenv("DB_HOST"); v != "" {
-		cfg.Database.Host = v
+
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("server shutdown timeout must be positive, got %v", c.Server.ShutdownTimeout)
 	}
-	if v := os.Getenv("DB_PORT"); v != "" {
-		if port, err := strconv.Atoi(v); err == nil {
-			cfg.Database.Port = port
-		}
+
+	if c.Session.MaxAge < 0 {
+		fail("session max age must be positive, got %v", c.Session.MaxAge)
 	}
-	if v := os.Getenv("DB_NAME"); v != "" {
-		cfg.Database.Name = v
+
+	if c.Session.AbsoluteTimeout < 0 {
+		fail("session absolute timeout must be positive, got %v", c.Session.AbsoluteTimeout)
 	}
-	if v := os.Getenv("DB_USER"); v != "" {
-		cfg.Database.User = v
+
+	if c.Session.CleanupInterval < 0 || c.Session.CleanupInterval > 0 && c.Session.CleanupInterval < time.Second {
+		fail("session cleanup interval must be at least 1s, or 0 to disable, got %v", c.Session.CleanupInterval)
 	}
-	if v := os.Getenv("DB_PASSWORD"); v != "" {
-		cfg.Database.Password = v
+
+	if c.Session.CookieName == "" {
+		fail("session cookie name must not be empty")
 	}
-	if v := os.Getenv("DB_SSL_MODE"); v != "" {
-		cfg.Database.SSLMode = v
+
+	sameSite, err := ParseSameSite(c.Session.SameSite)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if sameSite == http.SameSiteNoneMode && !c.Session.Secure {
+		fail("session same site 'none' requires secure cookies")
 	}
-}
 
-// Validate validates the configuration
-func (c *Config) Validate() error {
-	if c.Server.Port < 1 || c.Server.Port > 65535 {
-		return fmt.Errorf("server port must be between 1 and 65535, got %d", c.Server.Port)
+	if c.Session.Path != "" && c.Session.Path[0] != '/' {
+		fail("session cookie path must start with '/', got '%s'", c.Session.Path)
 	}
 
-	if c.Server.ReadTimeout < 0 {
-		return fmt.Errorf("server read timeout must be positive, got %v", c.Server.ReadTimeout)
+	for i, key := range c.Session.SecretKeys {
+		if len(key) < 32 {
+			fail("session secret key %d must be at least 32 characters", i+1)
+		}
 	}
 
-	if c.Server.WriteTimeout < 0 {
-		return fmt.Errorf("server write timeout must be positive, got %v", c.Server.WriteTimeout)
+	if c.Session.MaxSessions < 0 {
+		fail("session max sessions must not be negative, got %d", c.Session.MaxSessions)
 	}
 
-	if c.Session.MaxAge < 0 {
-		return fmt.Errorf("session max age must be positive, got %v", c.Session.MaxAge)
+	validSessionStores := map[string]bool{"memory": true, "cookie": true}
+	if !validSessionStores[c.Session.Store] {
+		fail("invalid session store '%s', must be one of: memory, cookie", c.Session.Store)
+	}
+
+	if c.Session.Store == "cookie" && c.Session.CookieMaxSize < 1 {
+		fail("session cookie max size must be positive, got %d", c.Session.CookieMaxSize)
+	}
+
+	if c.Session.HostPrefix {
+		if !c.Session.Secure {
+			fail("session __Host- prefix requires secure cookies")
+		}
+		if c.Session.Domain != "" {
+			fail("session __Host- prefix requires an empty cookie domain, got '%s'", c.Session.Domain)
+		}
+		if c.Session.Path != "/" {
+			fail("session __Host- prefix requires cookie path '/', got '%s'", c.Session.Path)
+		}
 	}
 
 	validLogLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLogLevels[c.Logging.Level] {
-		return fmt.Errorf("invalid log level '%s', must be one of: debug, info, warn, error", c.Logging.Level)
+		fail("invalid log level '%s', must be one of: debug, info, warn, error", c.Logging.Level)
 	}
 
 	validLogFormats := map[string]bool{"json": true, "text": true}
 	if !validLogFormats[c.Logging.Format] {
-		return fmt.Errorf("invalid log format '%s', must be one of: json, text", c.Logging.Format)
+		fail("invalid log format '%s', must be one of: json, text", c.Logging.Format)
+	}
+
+	validDrivers := map[string]bool{"sqlite3": true, "postgres": true, "mysql": true}
+	if !validDrivers[c.Database.Driver] {
+		fail("invalid database driver '%s', must be one of: sqlite3, postgres, mysql", c.Database.Driver)
+	}
+
+	if c.Database.Driver != "sqlite3" && (c.Database.Port < 1 || c.Database.Port > 65535) {
+		fail("database port must be between 1 and 65535, got %d", c.Database.Port)
+	}
+
+	if c.Database.MaxOpenConns < 0 {
+		fail("database max open connections must not be negative, got %d", c.Database.MaxOpenConns)
+	}
+
+	if c.Database.MaxIdleConns < 0 {
+		fail("database max idle connections must not be negative, got %d", c.Database.MaxIdleConns)
+	}
+
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("database max idle connections (%d) must not exceed max open connections (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	if c.Database.ConnMaxLifetime < 0 {
+		fail("database connection max lifetime must be positive, got %v", c.Database.ConnMaxLifetime)
+	}
+
+	if c.Database.ConnMaxIdleTime < 0 {
+		fail("database connection max idle time must be positive, got %v", c.Database.ConnMaxIdleTime)
 	}
 
+	if c.Database.ConnectTimeout <= 0 {
+		fail("database connect timeout must be positive, got %v", c.Database.ConnectTimeout)
+	}
+
+	validSSLModes := map[string]bool{"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true}
+	if !validSSLModes[c.Database.SSLMode] {
+		fail("invalid database ssl mode '%s', must be one of: disable, allow, prefer, require, verify-ca, verify-full", c.Database.SSLMode)
+	}
+
+	// The mysql DSN cannot escape the user, and its first ':' ends the user
+	if c.Database.Driver == "mysql" && strings.Contains(c.Database.User, ":") {
+		fail("database user must not contain ':' for mysql")
+	}
+
+	if c.base == EnvProduction {
+		if !c.Session.Secure {
+			fail("session cookies must be secure in production")
+		}
+		if c.Database.Driver == "postgres" && c.Database.Password == "" {
+			fail("database password must be set for postgres in production")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAddress checks that the public base URL is an absolute http or https URL
+func validateAddress(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("invalid server address '%s': %w", address, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid server address '%s', must be an http or https URL", address)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid server address '%s', must include a host", address)
+	}
 	return nil
 }
 
@@ -342,24 +536,37 @@ func (c *Config) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
 
-// GetDatabaseURL returns the database connection URL
-func (c *Config) GetDatabaseURL() string {
-	switch c.Database.Driver {
-	case "postgres":
-		return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-			c.Database.User, c.Database.Password, c.Database.Host,
-			c.Database.Port, c.Database.Name, c.Database.SSLMode)
-	case "mysql":
-		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-			c.Database.User, c.Database.Password, c.Database.Host,
-			c.Database.Port, c.Database.Name)
-	case "sqlite3":
-		return c.Database.Name
+// ParseSameSite converts a SameSite setting (lax, strict or none) to its http.SameSite mode
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch value {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
 	default:
-		return c.Database.Name
+		return http.SameSiteDefaultMode, fmt.Errorf("invalid session same site '%s', must be one of: lax, strict, none", value)
 	}
 }
 
+// GetSessionCookieName returns the session cookie name, including the __Host- prefix when enabled
+func (c *Config) GetSessionCookieName() string {
+	if c.Session.HostPrefix {
+		return "__Host-" + c.Session.CookieName
+	}
+	return c.Session.CookieName
+}
+
+// GetSessionSameSite returns the validated SameSite mode for session cookies
+func (c *Config) GetSessionSameSite() http.SameSite {
+	mode, err := ParseSameSite(c.Session.SameSite)
+	if err != nil {
+		return http.SameSiteDefaultMode
+	}
+	return mode
+}
+
 // Helper functions for environment variable parsing
 
 func getEnv(key, defaultValue string) string {
@@ -369,32 +576,28 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
-		slog.Warn("Invalid integer value for environment variable", "key", key, "value", value, "using_default", defaultValue)
 	}
-	return defaultValue
+	return items
 }
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
-		}
-		slog.Warn("Invalid boolean value for environment variable", "key", key, "value", value, "using_default", defaultValue)
-	}
-	return defaultValue
+// EnvError describes an environment variable whose value could not be parsed
+type EnvError struct {
+	Key   string
+	Value string
+	Err   error
 }
 
-func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
-		slog.Warn("Invalid duration value for environment variable", "key", key, "value", value, "using_default", defaultValue)
-	}
-	return defaultValue
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("%s=%q: %v", e.Key, e.Value, e.Err)
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
 }
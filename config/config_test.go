@@ -0,0 +1,322 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateLoad runs the test in a temporary directory with a fresh record of
+// the process environment and .env file, restoring both afterwards.
+func isolateLoad(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	resetDotEnv := func() {
+		dotEnvMu.Lock()
+		defer dotEnvMu.Unlock()
+		for key := range dotEnvKeys {
+			os.Unsetenv(key)
+		}
+		processEnv, dotEnvKeys = nil, nil
+	}
+	resetDotEnv()
+	t.Cleanup(func() {
+		resetDotEnv()
+		os.Chdir(wd)
+	})
+	return dir
+}
+
+// writeFile writes content to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWithOptionsPrecedence(t *testing.T) {
+	// Each layer sets one setting more than the layer above it
+	t.Setenv("DB_NAME", "env.db")
+	t.Setenv("SERVER_HOST", "env-host")
+	dir := isolateLoad(t)
+
+	configFile := writeFile(t, dir, "config.toml", `
+[server]
+port = 1111
+host = "file-host"
+
+[session]
+cookie_name = "file_cookie"
+
+[database]
+name = "file.db"
+`)
+	writeFile(t, dir, ".env", "SESSION_COOKIE_NAME=dotenv_cookie\nDB_NAME=dotenv.db\nSERVER_HOST=dotenv-host\n")
+
+	cfg, err := LoadWithOptions(Options{
+		ConfigFile:  configFile,
+		Environment: EnvDevelopment,
+		Overrides:   map[string]string{"SERVER_HOST": "flag-host"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := make(map[string]SettingSource)
+	for _, src := range cfg.Sources() {
+		sources[src.Setting.Env] = src
+	}
+	if len(sources) != len(Settings(cfg)) {
+		t.Errorf("Sources() returned %d settings, want %d", len(sources), len(Settings(cfg)))
+	}
+
+	tests := []struct {
+		env    string
+		value  string
+		source Source
+	}{
+		{"LOG_LEVEL", "debug", SourceDefault},
+		{"SERVER_PORT", "1111", SourceFile},
+		{"SESSION_COOKIE_NAME", "dotenv_cookie", SourceDotEnv},
+		{"DB_NAME", "env.db", SourceEnv},
+		{"SERVER_HOST", "flag-host", SourceFlag},
+	}
+	for _, tt := range tests {
+		src := sources[tt.env]
+		if got := src.Setting.Reveal(); got != tt.value || src.Source != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.env, got, src.Source, tt.value, tt.source)
+		}
+	}
+}
+
+func TestLoadWithOptionsEmptyVariables(t *testing.T) {
+	// An empty string variable clears the setting; empty secrets and
+	// non-string settings, as written by the env template, are left alone
+	t.Setenv("SESSION_SNAPSHOT_FILE", "")
+	t.Setenv("SESSION_SECRET_KEYS", "")
+	t.Setenv("SERVER_PORT", "")
+	isolateLoad(t)
+
+	cfg, err := LoadWithOptions(Options{Environment: EnvDevelopment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Session.SnapshotFile != "" {
+		t.Errorf("SnapshotFile = %q, want it cleared", cfg.Session.SnapshotFile)
+	}
+	if len(cfg.Session.SecretKeys) == 0 {
+		t.Error("SecretKeys cleared, want the development default")
+	}
+	if cfg.Server.Port != 9779 {
+		t.Errorf("Port = %d, want the default 9779", cfg.Server.Port)
+	}
+}
+
+func TestLoadWithOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		configFile string // TOML content, empty for none
+		env        map[string]string
+		overrides  map[string]string
+		wantErrs   []string
+	}{
+		{
+			name:     "every malformed variable is reported",
+			env:      map[string]string{"SERVER_PORT": "abc", "SESSION_MAX_AGE": "forever"},
+			wantErrs: []string{"SERVER_PORT=\"abc\"", "SESSION_MAX_AGE=\"forever\""},
+		},
+		{
+			name:       "unknown config file key",
+			configFile: "[server]\nprot = 1\n",
+			wantErrs:   []string{`unknown setting "server.prot"`},
+		},
+		{
+			name:      "malformed flag",
+			overrides: map[string]string{"SERVER_PORT": "x"},
+			wantErrs:  []string{`--port="x"`},
+		},
+		{
+			name:     "secret values are not echoed",
+			env:      map[string]string{"SESSION_SECRET_KEYS": "too-short"},
+			wantErrs: []string{"session secret key 1 must be at least 32 characters"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			dir := isolateLoad(t)
+			opts := Options{Environment: EnvDevelopment, Overrides: tt.overrides}
+			if tt.configFile != "" {
+				opts.ConfigFile = writeFile(t, dir, "config.toml", tt.configFile)
+			}
+
+			_, err := LoadWithOptions(opts)
+			if err == nil {
+				t.Fatal("LoadWithOptions succeeded, want an error")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+			if strings.Contains(err.Error(), "too-short") {
+				t.Errorf("error %q reveals a secret", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	validKeys := []Secret{Secret(strings.Repeat("k", 32))}
+
+	tests := []struct {
+		name     string
+		env      Environment
+		edit     func(cfg *Config)
+		wantErrs []string // Every message must be reported; none means valid
+	}{
+		{
+			name: "development defaults",
+			env:  EnvDevelopment,
+			edit: func(cfg *Config) {},
+		},
+		{
+			name: "production with secure settings",
+			env:  EnvProduction,
+			edit: func(cfg *Config) {
+				cfg.Session.SecretKeys = validKeys
+				cfg.Database.Password = "secret"
+			},
+		},
+		{
+			name: "every problem is reported",
+			env:  EnvDevelopment,
+			edit: func(cfg *Config) {
+				cfg.Server.Port = 0
+				cfg.Server.Address = "localhost"
+				cfg.Session.SameSite = "sometimes"
+				cfg.Logging.Level = "loud"
+				cfg.Database.MaxOpenConns = 2
+				cfg.Database.MaxIdleConns = 3
+			},
+			wantErrs: []string{
+				"server port must be between 1 and 65535, got 0",
+				"invalid server address 'localhost'",
+				"invalid session same site 'sometimes'",
+				"invalid log level 'loud'",
+				"database max idle connections (3) must not exceed max open connections (2)",
+			},
+		},
+		{
+			name:     "same site none needs secure",
+			env:      EnvDevelopment,
+			edit:     func(cfg *Config) { cfg.Session.SameSite = "none" },
+			wantErrs: []string{"session same site 'none' requires secure cookies"},
+		},
+		{
+			name: "host prefix rules",
+			env:  EnvDevelopment,
+			edit: func(cfg *Config) {
+				cfg.Session.HostPrefix = true
+				cfg.Session.Domain = "example.com"
+				cfg.Session.Path = "/app"
+			},
+			wantErrs: []string{
+				"session __Host- prefix requires secure cookies",
+				"session __Host- prefix requires an empty cookie domain",
+				"session __Host- prefix requires cookie path '/'",
+			},
+		},
+		{
+			name:     "short secret key",
+			env:      EnvDevelopment,
+			edit:     func(cfg *Config) { cfg.Session.SecretKeys = append(validKeys, "short") },
+			wantErrs: []string{"session secret key 2 must be at least 32 characters"},
+		},
+		{
+			name: "production requires secure cookies and a postgres password",
+			env:  EnvProduction,
+			edit: func(cfg *Config) {
+				cfg.Session.SecretKeys = validKeys
+				cfg.Session.Secure = false
+			},
+			wantErrs: []string{
+				"session cookies must be secure in production",
+				"database password must be set for postgres in production",
+			},
+		},
+		{
+			name: "production password rule is postgres only",
+			env:  EnvProduction,
+			edit: func(cfg *Config) {
+				cfg.Session.SecretKeys = validKeys
+				cfg.Database.Driver = "mysql"
+				cfg.Database.Port = 3306
+			},
+		},
+		{
+			name: "mysql user cannot contain a colon",
+			env:  EnvDevelopment,
+			edit: func(cfg *Config) {
+				cfg.Database.Driver = "mysql"
+				cfg.Database.User = "app:admin"
+			},
+			wantErrs: []string{"database user must not contain ':' for mysql"},
+		},
+		{
+			name: "postgres user may contain a colon",
+			env:  EnvDevelopment,
+			edit: func(cfg *Config) {
+				cfg.Database.Driver = "postgres"
+				cfg.Database.User = "app:admin"
+			},
+		},
+		{
+			name: "staging allows insecure cookies",
+			env:  EnvStaging,
+			edit: func(cfg *Config) { cfg.Session.Secure = false },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Defaults(tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(cfg)
+
+			err = cfg.Validate()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() succeeded, want errors")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %q, missing %q", err, want)
+				}
+			}
+			if got := strings.Count(err.Error(), "\n") + 1; got != len(tt.wantErrs) {
+				t.Errorf("Validate() reported %d problems, want %d:\n%v", got, len(tt.wantErrs), err)
+			}
+		})
+	}
+}
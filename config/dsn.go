@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// redactedPassword replaces the password in logged connection strings,
+// matching url.URL.Redacted
+const redactedPassword = "xxxxx"
+
+// sqlitePragmas are applied to every sqlite3 connection unless DB_OPTIONS overrides them
+var sqlitePragmas = map[string]string{
+	"_foreign_keys": "on",
+	"_journal_mode": "WAL",
+	"_busy_timeout": "5000",
+}
+
+// GetDatabaseURL returns the connection string for the configured driver,
+// with every component escaped. It embeds the password, so it is returned as
+// a Secret; use RedactedDatabaseURL for logging.
+func (c *Config) GetDatabaseURL() Secret {
+	return Secret(c.Database.dsn(c.Database.Password.Reveal()))
+}
+
+// RedactedDatabaseURL returns the connection string with the password masked, safe to log
+func (c *Config) RedactedDatabaseURL() string {
+	password := ""
+	if c.Database.Password != "" {
+		password = redactedPassword
+	}
+	return c.Database.dsn(password)
+}
+
+// dsn builds the connection string for the configured driver with the given password
+func (db DatabaseConfig) dsn(password string) string {
+	switch db.Driver {
+	case "postgres":
+		return db.postgresDSN(password)
+	case "mysql":
+		return db.mysqlDSN(password)
+	default:
+		return db.sqliteDSN()
+	}
+}
+
+// postgresDSN builds a postgres:// URL as accepted by lib/pq and pgx
+func (db DatabaseConfig) postgresDSN(password string) string {
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(db.Host, strconv.Itoa(db.Port)),
+		Path:   "/" + db.Name,
+	}
+	switch {
+	case db.User != "" && password != "":
+		u.User = url.UserPassword(db.User, password)
+	case db.User != "":
+		u.User = url.User(db.User)
+	}
+	u.RawQuery = db.params(map[string]string{"sslmode": db.SSLMode}).Encode()
+	return u.String()
+}
+
+// mysqlDSN builds a go-sql-driver/mysql DSN: user:password@tcp(host:port)/name?params
+//
+// The driver has no escaping for the user and password. It splits them at the
+// last '@' and the first ':', so any password works but Validate rejects a
+// user containing ':'.
+func (db DatabaseConfig) mysqlDSN(password string) string {
+	var b strings.Builder
+	if db.User != "" {
+		b.WriteString(db.User)
+		if password != "" {
+			b.WriteString(":" + password)
+		}
+		b.WriteString("@")
+	}
+	fmt.Fprintf(&b, "tcp(%s)/%s", net.JoinHostPort(db.Host, strconv.Itoa(db.Port)), url.PathEscape(db.Name))
+
+	// clientFoundRows makes UPDATE report matched rather than changed rows,
+	// as postgres and sqlite do
+	params := db.params(map[string]string{
+		"parseTime":       "true",
+		"clientFoundRows": "true",
+		"tls":             mysqlTLS(db.SSLMode),
+	})
+	b.WriteString("?" + params.Encode())
+	return b.String()
+}
+
+// sqliteDSN builds a file: URI for mattn/go-sqlite3 with the default pragmas
+func (db DatabaseConfig) sqliteDSN() string {
+	path := (&url.URL{Path: db.Name}).EscapedPath()
+	return "file:" + path + "?" + db.params(sqlitePragmas).Encode()
+}
+
+// params merges DB_OPTIONS over a driver's built-in connection parameters
+func (db DatabaseConfig) params(builtin map[string]string) url.Values {
+	params := url.Values{}
+	for k, v := range builtin {
+		params.Set(k, v)
+	}
+	for k, v := range db.Options {
+		params.Set(k, v)
+	}
+	return params
+}
+
+// mysqlTLS maps a postgres-style SSL mode onto go-sql-driver/mysql's tls parameter
+func mysqlTLS(sslMode string) string {
+	switch sslMode {
+	case "disable":
+		return "false"
+	case "allow", "prefer":
+		return "preferred"
+	case "require":
+		return "skip-verify"
+	default:
+		return "true"
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPostgresDSNEscaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		dbName   string
+	}{
+		{"plain", "app", "secret", "app_prod"},
+		{"at sign", "app", "p@ss@word", "app_prod"},
+		{"slash", "app", "pa/ss//word", "app_prod"},
+		{"colon", "app:admin", "pa:ss", "app_prod"},
+		{"everything", "a@b", "@/:?#% &=", "my db"},
+		{"no password", "app", "", "app_prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := DatabaseConfig{Driver: "postgres", Host: "db.example.com", Port: 5432,
+				Name: tt.dbName, User: tt.user, Password: Secret(tt.password), SSLMode: "require"}
+
+			u, err := url.Parse(db.dsn(tt.password))
+			if err != nil {
+				t.Fatal(err)
+			}
+			password, hasPassword := u.User.Password()
+			if u.User.Username() != tt.user || password != tt.password || hasPassword != (tt.password != "") {
+				t.Errorf("user %q, password %q; want %q, %q", u.User.Username(), password, tt.user, tt.password)
+			}
+			if u.Hostname() != "db.example.com" || u.Port() != "5432" || u.Path != "/"+tt.dbName {
+				t.Errorf("host %q, port %q, path %q", u.Hostname(), u.Port(), u.Path)
+			}
+			if got := u.Query().Get("sslmode"); got != "require" {
+				t.Errorf("sslmode = %q, want require", got)
+			}
+		})
+	}
+}
+
+func TestMySQLDSNEscaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"plain", "secret"},
+		{"at sign", "p@ss@word"},
+		{"slash", "pa/ss//word"},
+		{"colon", "pa:ss:word"},
+		{"everything", "@/:?#% &=("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := DatabaseConfig{Driver: "mysql", Host: "db.example.com", Port: 3306,
+				Name: "app_prod", User: "app", Password: Secret(tt.password), SSLMode: "disable"}
+
+			parsed, err := mysql.ParseDSN(db.dsn(tt.password))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.User != "app" || parsed.Passwd != tt.password {
+				t.Errorf("user %q, password %q; want app, %q", parsed.User, parsed.Passwd, tt.password)
+			}
+			if parsed.Net != "tcp" || parsed.Addr != "db.example.com:3306" || parsed.DBName != "app_prod" {
+				t.Errorf("net %q, addr %q, database %q", parsed.Net, parsed.Addr, parsed.DBName)
+			}
+			if !parsed.ParseTime || !parsed.ClientFoundRows {
+				t.Errorf("parseTime %v, clientFoundRows %v, want both true", parsed.ParseTime, parsed.ClientFoundRows)
+			}
+		})
+	}
+}
+
+func TestDSNOptionsAndRedaction(t *testing.T) {
+	tests := []struct {
+		name string
+		db   DatabaseConfig
+		want []string // Substrings of the redacted DSN
+	}{
+		{
+			name: "postgres",
+			db: DatabaseConfig{Driver: "postgres", Host: "::1", Port: 5432, Name: "app", User: "app",
+				Password: "p@ss", SSLMode: "disable", Options: map[string]string{"sslmode": "verify-full", "application_name": "web"}},
+			want: []string{"postgres://app:xxxxx@[::1]:5432/app?", "sslmode=verify-full", "application_name=web"},
+		},
+		{
+			name: "mysql",
+			db: DatabaseConfig{Driver: "mysql", Host: "localhost", Port: 3306, Name: "app", User: "app",
+				Password: "p@ss", SSLMode: "require"},
+			want: []string{"app:xxxxx@tcp(localhost:3306)/app?", "tls=skip-verify"},
+		},
+		{
+			name: "sqlite",
+			db:   DatabaseConfig{Driver: "sqlite3", Name: "data/my app.db", Options: map[string]string{"_busy_timeout": "100"}},
+			want: []string{"file:data/my%20app.db?", "_foreign_keys=on", "_busy_timeout=100"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Database: tt.db}
+			got := cfg.RedactedDatabaseURL()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("RedactedDatabaseURL() = %q, missing %q", got, want)
+				}
+			}
+			if tt.db.Password != "" && strings.Contains(got, tt.db.Password.Reveal()) {
+				t.Errorf("RedactedDatabaseURL() = %q reveals the password", got)
+			}
+		})
+	}
+}
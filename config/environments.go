@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// builtinEnvironments are the environments with defaults declared in struct tags
+var builtinEnvironments = []Environment{EnvDevelopment, EnvStaging, EnvProduction}
+
+// profilesKey is the config file table holding named environment profiles
+const profilesKey = "environments"
+
+// profile is a named environment defined in the config file. It inherits
+// every default from its base, which must be set, then applies its own settings.
+type profile struct {
+	base   Environment
+	values map[string]string // Flattened setting keys, as in the rest of the config file
+}
+
+// IsBuiltin reports whether env is development, staging or production
+func (env Environment) IsBuiltin() bool {
+	for _, builtin := range builtinEnvironments {
+		if env == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// splitProfiles removes the environments table from flattened config file
+// values and returns the profiles it defines, e.g.
+//
+//	[environments.preview]
+//	base = "staging"
+//	server.address = "https://preview.example.com"
+func splitProfiles(values map[string]string) (map[Environment]*profile, error) {
+	profiles := make(map[Environment]*profile)
+	for key, value := range values {
+		rest, ok := strings.CutPrefix(key, profilesKey+".")
+		if !ok {
+			continue
+		}
+		delete(values, key)
+
+		name, settingKey, ok := strings.Cut(rest, ".")
+		if !ok {
+			return nil, fmt.Errorf("%s must be a table of environment profiles", key)
+		}
+		env := Environment(name)
+		if env.IsBuiltin() {
+			return nil, fmt.Errorf("environment %q is built in and cannot be redefined; define a profile with base = %q instead", name, name)
+		}
+		p := profiles[env]
+		if p == nil {
+			p = &profile{values: make(map[string]string)}
+			profiles[env] = p
+		}
+		if settingKey == "base" {
+			p.base = Environment(value)
+			continue
+		}
+		p.values[settingKey] = value
+	}
+	return profiles, nil
+}
+
+// resolveEnvironment follows env's chain of profile bases down to a built-in
+// environment. It returns that environment and the profiles to apply, from
+// the one closest to the built-in environment to env itself.
+func resolveEnvironment(env Environment, profiles map[Environment]*profile) (Environment, []Environment, error) {
+	var chain []Environment
+	seen := make(map[Environment]bool)
+	for !env.IsBuiltin() {
+		p, ok := profiles[env]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown environment %q, must be one of: %s", env, strings.Join(environmentNames(profiles), ", "))
+		}
+		if seen[env] {
+			return "", nil, fmt.Errorf("environment %q inherits from itself", env)
+		}
+		if p.base == "" {
+			return "", nil, fmt.Errorf("environment %q must set base to a built-in environment or another profile", env)
+		}
+		seen[env] = true
+		chain = append([]Environment{env}, chain...)
+		env = p.base
+	}
+	return env, chain, nil
+}
+
+// environmentNames lists the built-in environments followed by the defined profiles
+func environmentNames(profiles map[Environment]*profile) []string {
+	names := make([]string, 0, len(builtinEnvironments)+len(profiles))
+	for _, env := range builtinEnvironments {
+		names = append(names, string(env))
+	}
+	custom := make([]string, 0, len(profiles))
+	for env := range profiles {
+		custom = append(custom, string(env))
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// checkProfiles reports unknown settings, malformed values and unresolvable
+// bases in every profile, so mistakes surface before the profile is used
+func checkProfiles(path string, profiles map[Environment]*profile) error {
+	var errs []error
+	for _, name := range environmentNames(profiles)[len(builtinEnvironments):] {
+		env := Environment(name)
+		if _, _, err := resolveEnvironment(env, profiles); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s.%s.base: %w", path, profilesKey, name, err))
+			continue
+		}
+		scratch := &Config{sources: make(map[string]Source)}
+		values := make(map[string]string, len(profiles[env].values))
+		for k, v := range profiles[env].values {
+			values[k] = v
+		}
+		if err := loadFromFile(scratch, path, profilesKey+"."+name+".", values); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
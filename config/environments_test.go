@@ -0,0 +1,129 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvironment(t *testing.T) {
+	values := map[string]string{
+		"server.port":                         "1111",
+		"environments.preview.base":           "staging",
+		"environments.preview.server.host":    "preview",
+		"environments.pr.base":                "preview",
+		"environments.loop-a.base":            "loop-b",
+		"environments.loop-b.base":            "loop-a",
+		"environments.self.base":              "self",
+		"environments.orphan.base":            "missing",
+		"environments.baseless.logging.level": "warn",
+	}
+	profiles, err := splitProfiles(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values["server.port"] != "1111" {
+		t.Errorf("splitProfiles left %v, want only server.port", values)
+	}
+
+	tests := []struct {
+		env       Environment
+		wantBase  Environment
+		wantChain []Environment
+		wantErr   string
+	}{
+		{env: EnvProduction, wantBase: EnvProduction},
+		{env: "preview", wantBase: EnvStaging, wantChain: []Environment{"preview"}},
+		{env: "pr", wantBase: EnvStaging, wantChain: []Environment{"preview", "pr"}},
+		{env: "loop-a", wantErr: "inherits from itself"},
+		{env: "self", wantErr: "inherits from itself"},
+		{env: "orphan", wantErr: `unknown environment "missing"`},
+		{env: "baseless", wantErr: "must set base"},
+		{env: "prod", wantErr: `unknown environment "prod"`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.env), func(t *testing.T) {
+			base, chain, err := resolveEnvironment(tt.env, profiles)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if base != tt.wantBase || !reflect.DeepEqual(chain, tt.wantChain) {
+				t.Errorf("got base %s, chain %v; want %s, %v", base, chain, tt.wantBase, tt.wantChain)
+			}
+		})
+	}
+}
+
+func TestSplitProfilesRejectsBuiltin(t *testing.T) {
+	_, err := splitProfiles(map[string]string{"environments.production.logging.level": "debug"})
+	if err == nil || !strings.Contains(err.Error(), "built in") {
+		t.Errorf("error = %v, want a redefined built-in environment error", err)
+	}
+}
+
+func TestLoadProfileChain(t *testing.T) {
+	t.Setenv("SESSION_SECRET_KEYS", strings.Repeat("k", 32)) // Required by staging
+	dir := isolateLoad(t)
+	configFile := writeFile(t, dir, "config.toml", `
+[logging]
+format = "text"
+
+[environments.preview]
+base = "staging"
+
+[environments.preview.server]
+address = "https://preview.example.com"
+port = 2222
+
+[environments.pr]
+base = "preview"
+
+[environments.pr.server]
+port = 3333
+
+[environments.baseless.logging]
+level = "warn"
+`)
+
+	// A profile without a base fails the whole file, even when it is not active
+	_, err := LoadWithOptions(Options{ConfigFile: configFile, Environment: "pr"})
+	if err == nil || !strings.Contains(err.Error(), "environments.baseless.base") {
+		t.Fatalf("error = %v, want the missing base reported", err)
+	}
+
+	configFile = writeFile(t, dir, "config.toml", `
+[logging]
+format = "text"
+
+[environments.preview]
+base = "staging"
+
+[environments.preview.server]
+address = "https://preview.example.com"
+port = 2222
+
+[environments.pr]
+base = "preview"
+
+[environments.pr.server]
+port = 3333
+`)
+	cfg, err := LoadWithOptions(Options{ConfigFile: configFile, Environment: "pr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Environment() != "pr" || cfg.BaseEnvironment() != EnvStaging {
+		t.Errorf("environment %s based on %s, want pr based on staging", cfg.Environment(), cfg.BaseEnvironment())
+	}
+	// Staging default, top-level file value, inherited profile value, own profile value
+	if cfg.Session.SameSite != "strict" || cfg.Logging.Format != "text" ||
+		cfg.Server.Address != "https://preview.example.com" || cfg.Server.Port != 3333 {
+		t.Errorf("same site %q, format %q, address %q, port %d", cfg.Session.SameSite, cfg.Logging.Format, cfg.Server.Address, cfg.Server.Port)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes the configuration as a JSON document in the same layout
+// accepted as a config file. Secret values are redacted.
+func (c *Config) WriteJSON(w io.Writer) error {
+	doc := make(map[string]map[string]any)
+	for _, s := range Settings(c) {
+		section, key, _ := strings.Cut(s.Key, ".")
+		if doc[section] == nil {
+			doc[section] = make(map[string]any)
+		}
+		doc[section][key] = s.jsonValue()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// jsonValue returns the setting's value for JSON output. Durations are
+// written in their string form, empty lists and maps as [] and {}, and
+// secrets redact themselves.
+func (s Setting) jsonValue() any {
+	switch {
+	case s.value.Type() == durationType:
+		return s.String()
+	case s.value.Kind() == reflect.Slice && s.value.IsNil():
+		return []string{}
+	case s.value.Kind() == reflect.Map && s.value.IsNil():
+		return map[string]string{}
+	default:
+		return s.Value()
+	}
+}
+
+// WriteEnv writes the configuration as KEY=value lines in .env syntax.
+// Secret values are redacted.
+func (c *Config) WriteEnv(w io.Writer) error {
+	for _, s := range Settings(c) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", s.Env, quoteEnvValue(s.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteEnvTemplate writes a commented .env template listing every setting
+// with its description and its default for env. Secrets are always left empty.
+func WriteEnvTemplate(w io.Writer, env Environment) error {
+	b := &strings.Builder{}
+	b.WriteString("# Environment Configuration\n")
+	b.WriteString("# Copy this file to .env and modify as needed\n")
+	b.WriteString("# Generated by: htmx_quickstart config env-template\n\n")
+	b.WriteString("# Environment (development, staging, production)\n")
+	fmt.Fprintf(b, "ENV=%s\n\n", env)
+	b.WriteString("# Optional JSON or TOML config file, layered below .env and process variables\n")
+	b.WriteString("CONFIG_FILE=\n")
+
+	section := ""
+	for _, s := range Settings(&Config{}) {
+		if name, _, _ := strings.Cut(s.Path, "."); name != section {
+			section = name
+			fmt.Fprintf(b, "\n# %s Configuration\n", section)
+		}
+
+		comment := s.Description
+		if required := s.field.Tag.Get("required"); required == "true" {
+			comment += " (required)"
+		} else if required != "" {
+			comment += " (required in " + strings.Join(splitList(required), ", ") + ")"
+		}
+		if s.Secret {
+			comment += "; can be read from " + s.Env + "_FILE"
+		}
+		if comment != "" {
+			fmt.Fprintf(b, "# %s\n", comment)
+		}
+
+		value, _ := s.Default(env)
+		if s.Secret {
+			value = ""
+		}
+		fmt.Fprintf(b, "%s=%s\n", s.Env, quoteEnvValue(value))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quoteEnvValue double-quotes a value when .env parsing would otherwise alter it
+func quoteEnvValue(value string) string {
+	if strings.ContainsAny(value, " \t#\"'\\\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
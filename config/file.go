@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// readConfigFile parses a JSON or TOML config file, chosen by extension, into
+// flattened "section.key" values in the string syntax accepted by Setting.Set.
+func readConfigFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file format '%s', must be .json or .toml", ext)
+	}
+
+	values := make(map[string]string)
+	if err := flattenConfig(doc, "", values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flattenConfig turns nested tables into dotted keys. Tables whose key is a
+// known map setting are kept as a single key=value list.
+func flattenConfig(doc map[string]any, prefix string, values map[string]string) error {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := prefix + k
+		switch v := doc[k].(type) {
+		case map[string]any:
+			if isMapSetting(key) {
+				pairs := make(map[string]string, len(v))
+				for mk, mv := range v {
+					pairs[mk] = fmt.Sprint(mv)
+				}
+				values[key] = formatMap(pairs)
+				continue
+			}
+			if err := flattenConfig(v, key+".", values); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// isMapSetting reports whether a config file key names a map-typed setting,
+// at the top level or within an environment profile.
+func isMapSetting(key string) bool {
+	if rest, ok := strings.CutPrefix(key, profilesKey+"."); ok {
+		_, key, _ = strings.Cut(rest, ".")
+	}
+	for _, s := range Settings(&Config{}) {
+		if s.Key == key {
+			return s.Type() == "map"
+		}
+	}
+	return false
+}
+
+// loadFromFile overrides configuration with flattened config file values,
+// rejecting keys that don't name a setting. prefix locates the values within
+// the file, e.g. "environments.preview.", and is shown in errors.
+func loadFromFile(cfg *Config, path, prefix string, values map[string]string) error {
+	var errs []error
+	for _, s := range Settings(cfg) {
+		value, ok := values[s.Key]
+		if !ok {
+			continue
+		}
+		delete(values, s.Key)
+		if err := s.Set(value); err != nil {
+			if s.Secret {
+				value = redacted
+			}
+			errs = append(errs, fmt.Errorf("%s: %s%s = %q: %w", path, prefix, s.Key, value, err))
+			continue
+		}
+		cfg.sources[s.Env] = SourceFile
+	}
+
+	unknown := make([]string, 0, len(values))
+	for key := range values {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, prefix+key))
+	}
+	return errors.Join(errs...)
+}
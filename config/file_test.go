@@ -0,0 +1,70 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	want := map[string]string{
+		"server.port":               "8080",
+		"session.secure":            "true",
+		"session.max_age":           "2h",
+		"session.secret_keys":       "new,old",
+		"database.options":          "application_name=web,connect_timeout=5",
+		"environments.preview.base": "staging",
+		"environments.preview.db_x": "1",
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "toml", file: "config.toml", content: `
+[server]
+port = 8080
+
+[session]
+secure = true
+max_age = "2h"
+secret_keys = ["new", "old"]
+
+[database.options]
+application_name = "web"
+connect_timeout = 5
+
+[environments.preview]
+base = "staging"
+db_x = 1
+`},
+		{name: "json", file: "config.json", content: `{
+	"server": {"port": 8080},
+	"session": {"secure": true, "max_age": "2h", "secret_keys": ["new", "old"]},
+	"database": {"options": {"application_name": "web", "connect_timeout": 5}},
+	"environments": {"preview": {"base": "staging", "db_x": 1}}
+}`},
+		{name: "unsupported extension", file: "config.yaml", content: "server:\n  port: 8080\n", wantErr: "unsupported config file format"},
+		{name: "malformed", file: "config.json", content: `{"server": `, wantErr: "parsing config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), tt.file, tt.content)
+			got, err := readConfigFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("readConfigFile() = %v\nwant %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Flags holds the command-line flags registered by RegisterFlags. Every
+// setting except secrets gets a flag named by its flag tag, or its env name
+// in lower case with dashes, e.g. --log-level for LOG_LEVEL. Secrets have no
+// flag because command lines are visible to other users through ps.
+type Flags struct {
+	ConfigFile  string
+	Environment string
+
+	values map[string]string // Raw values of the setting flags given, by env name
+}
+
+// settingFlag records the raw value of a setting flag for Load to parse
+type settingFlag struct {
+	setting Setting
+	values  map[string]string
+}
+
+func (f *settingFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return f.values[f.setting.Env]
+}
+
+func (f *settingFlag) Set(value string) error {
+	f.values[f.setting.Env] = value
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given as --flag without a value
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.setting.Type() == "bool"
+}
+
+// RegisterFlags adds --config, --env and a flag for every non-secret setting
+// to fs, and replaces its usage message with a list of every setting, its
+// environment variables and its defaults, marking secrets as env-only.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{values: make(map[string]string)}
+	fs.StringVar(&flags.ConfigFile, "config", "", "Path to a JSON or TOML config file")
+	fs.StringVar(&flags.Environment, "env", "", "Environment: development, staging, production or a profile from the config file")
+
+	settings := Settings(&Config{})
+	for _, s := range settings {
+		if !s.Secret {
+			fs.Var(&settingFlag{setting: s, values: flags.values}, s.Flag, s.Description)
+		}
+	}
+
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: %s [flags]\n\n", fs.Name())
+		fmt.Fprintln(w, "Flags take precedence over environment variables, .env and the config file.")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "  --config string")
+		fmt.Fprintln(w, "        Path to a JSON or TOML config file (env CONFIG_FILE)")
+		fmt.Fprintln(w, "  --env string")
+		fmt.Fprintln(w, "        Environment: development, staging, production or a profile from the config file (env ENV, default development)")
+		var secrets []Setting
+		for _, s := range settings {
+			if s.Secret {
+				secrets = append(secrets, s)
+				continue
+			}
+			fmt.Fprintf(w, "  --%s", s.Flag)
+			if s.Type() != "bool" {
+				fmt.Fprintf(w, " %s", s.Type())
+			}
+			fmt.Fprintf(w, "\n        %s (env %s%s)\n", s.Description, s.Env, s.usageDefaults())
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Secrets have no flag and are read only from the environment, .env or the config file:")
+		fmt.Fprintln(w)
+		for _, s := range secrets {
+			fmt.Fprintf(w, "  %s\n        %s (env %s, or %s_FILE)\n", s.Env, s.Description, s.Env, s.Env)
+		}
+	}
+	return flags
+}
+
+// usageDefaults describes the setting's defaults for the help message
+func (s Setting) usageDefaults() string {
+	var parts []string
+	if value, ok := s.field.Tag.Lookup("default"); ok && value != "" {
+		parts = append(parts, "default "+value)
+	}
+	for _, env := range []Environment{EnvDevelopment, EnvStaging, EnvProduction} {
+		if value, ok := s.field.Tag.Lookup(string(env)); ok {
+			if value == "" {
+				value = `""`
+			}
+			parts = append(parts, string(env)+" "+value)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(parts, "; ")
+}
+
+// Options returns the load options selected by the parsed flags
+func (f *Flags) Options() Options {
+	return Options{
+		ConfigFile:  f.ConfigFile,
+		Environment: Environment(f.Environment),
+		Overrides:   f.values,
+	}
+}
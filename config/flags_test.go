@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSettingsKeysAndFlags(t *testing.T) {
+	tests := []struct {
+		env  string
+		key  string
+		flag string
+	}{
+		{"SERVER_PORT", "server.port", "port"},
+		{"SESSION_SAME_SITE", "session.same_site", "session-same-site"},
+		{"DB_SSL_MODE", "database.ssl_mode", "db-ssl-mode"},
+		{"DB_MAX_OPEN_CONNS", "database.max_open_conns", "db-max-open-conns"},
+	}
+	for _, tt := range tests {
+		s := setting(t, &Config{}, tt.env)
+		if s.Key != tt.key || s.Flag != tt.flag {
+			t.Errorf("%s: key %q, flag %q; want %q, %q", tt.env, s.Key, s.Flag, tt.key, tt.flag)
+		}
+	}
+}
+
+func TestRegisterFlagsParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    Options
+		wantErr bool
+	}{
+		{
+			name: "none",
+			want: Options{Overrides: map[string]string{}},
+		},
+		{
+			name: "settings and selectors",
+			args: []string{"--config", "app.toml", "--env=staging", "--port", "8081", "--log-level=warn", "--session-secure", "--session-domain="},
+			want: Options{
+				ConfigFile:  "app.toml",
+				Environment: EnvStaging,
+				Overrides: map[string]string{
+					"SERVER_PORT":    "8081",
+					"LOG_LEVEL":      "warn",
+					"SESSION_SECURE": "true",
+					"SESSION_DOMAIN": "",
+				},
+			},
+		},
+		{
+			name: "bool flag with value",
+			args: []string{"--session-secure=false"},
+			want: Options{Overrides: map[string]string{"SESSION_SECURE": "false"}},
+		},
+		{name: "secrets have no flag", args: []string{"--db-password", "x"}, wantErr: true},
+		{name: "secret keys have no flag", args: []string{"--session-secret-keys", "x"}, wantErr: true},
+		{name: "unknown flag", args: []string{"--no-such-setting", "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			flags := RegisterFlags(fs)
+
+			err := fs.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := flags.Options(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Options() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterFlagsHelp(t *testing.T) {
+	var out bytes.Buffer
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	RegisterFlags(fs)
+	fs.Usage()
+	help := out.String()
+
+	for _, s := range Settings(&Config{}) {
+		if s.Secret {
+			if !strings.Contains(help, "env "+s.Env+", or "+s.Env+"_FILE") {
+				t.Errorf("help does not list secret %s as env-only", s.Env)
+			}
+			if strings.Contains(help, "--"+s.Flag) {
+				t.Errorf("help lists a flag for secret %s", s.Env)
+			}
+			continue
+		}
+		if !strings.Contains(help, "--"+s.Flag) || !strings.Contains(help, "(env "+s.Env) {
+			t.Errorf("help does not list --%s with env %s", s.Flag, s.Env)
+		}
+	}
+	for _, want := range []string{
+		"--config string",
+		"--port int",
+		"default 9779; staging 8080; production 8080",
+		"--session-max-age duration",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help is missing %q", want)
+		}
+	}
+	if strings.Contains(help, "insecure-development-session-key") {
+		t.Error("help reveals the development secret key")
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Live holds the running configuration and lets it be replaced while the
+// server runs. Only settings tagged reload:"true" can change; every other
+// setting keeps the value the process started with.
+type Live struct {
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex // Serializes reloads and subscriber registration
+	subscribers []func(*Config)
+}
+
+// Change describes a setting whose value differs between two configurations.
+// Secret values are redacted.
+type Change struct {
+	Setting string // Environment variable name
+	Old     string
+	New     string
+}
+
+// NewLive creates a Live configuration starting from cfg
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(cfg)
+	return l
+}
+
+// Get returns the current configuration. The returned Config must not be modified.
+func (l *Live) Get() *Config {
+	return l.current.Load()
+}
+
+// Subscribe registers fn to be called with the new configuration after each
+// reload that changes a setting
+func (l *Live) Subscribe(fn func(*Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Reload applies the reloadable settings of next and notifies subscribers.
+// Changes to other settings are refused: next keeps the running value for
+// them, and they are returned for the caller to log. Nothing is applied if
+// the resulting configuration fails validation.
+func (l *Live) Reload(next *Config) (applied, refused []Change, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.Get()
+	if next.environment != current.environment {
+		return nil, nil, fmt.Errorf("environment cannot change from %s to %s without a restart", current.environment, next.environment)
+	}
+
+	running := Settings(current)
+	for i, s := range Settings(next) {
+		old := running[i]
+		if s.Reveal() == old.Reveal() {
+			continue
+		}
+		change := Change{Setting: s.Env, Old: old.String(), New: s.String()}
+		if s.Reloadable {
+			applied = append(applied, change)
+			continue
+		}
+		refused = append(refused, change)
+		s.value.Set(old.value)
+		if source, ok := current.sources[s.Env]; ok {
+			next.sources[s.Env] = source
+		} else {
+			delete(next.sources, s.Env)
+		}
+	}
+
+	if len(applied) == 0 {
+		return nil, refused, nil
+	}
+	if err := next.Validate(); err != nil {
+		return nil, refused, err
+	}
+
+	l.current.Store(next)
+	for _, fn := range l.subscribers {
+		fn(next)
+	}
+	return applied, refused, nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newLive returns a Live development configuration and a counter of
+// subscriber notifications.
+func newLive(t *testing.T) (*Live, *int) {
+	t.Helper()
+	cfg, err := Defaults(EnvDevelopment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLive(cfg)
+	notified := new(int)
+	l.Subscribe(func(*Config) { *notified++ })
+	return l, notified
+}
+
+// nextConfig returns the defaults for env modified by edit.
+func nextConfig(t *testing.T, env Environment, edit func(cfg *Config)) *Config {
+	t.Helper()
+	cfg, err := Defaults(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit(cfg)
+	return cfg
+}
+
+func TestLiveReload(t *testing.T) {
+	tests := []struct {
+		name         string
+		edit         func(cfg *Config)
+		wantApplied  []Change
+		wantRefused  []Change
+		wantErr      bool
+		wantNotified int
+		wantLevel    string
+		wantPort     int
+	}{
+		{
+			name:         "applies reloadable change",
+			edit:         func(cfg *Config) { cfg.Logging.Level = "warn" },
+			wantApplied:  []Change{{Setting: "LOG_LEVEL", Old: "debug", New: "warn"}},
+			wantNotified: 1,
+			wantLevel:    "warn",
+			wantPort:     9779,
+		},
+		{
+			name:        "refuses restart-only change",
+			edit:        func(cfg *Config) { cfg.Server.Port = 1234; cfg.sources["SERVER_PORT"] = SourceEnv },
+			wantRefused: []Change{{Setting: "SERVER_PORT", Old: "9779", New: "1234"}},
+			wantLevel:   "debug",
+			wantPort:    9779,
+		},
+		{
+			name: "applies reloadable and refuses restart-only",
+			edit: func(cfg *Config) {
+				cfg.Logging.Level = "error"
+				cfg.Server.Port = 1234
+				cfg.sources["SERVER_PORT"] = SourceEnv
+			},
+			wantApplied:  []Change{{Setting: "LOG_LEVEL", Old: "debug", New: "error"}},
+			wantRefused:  []Change{{Setting: "SERVER_PORT", Old: "9779", New: "1234"}},
+			wantNotified: 1,
+			wantLevel:    "error",
+			wantPort:     9779,
+		},
+		{
+			name:      "unchanged",
+			edit:      func(cfg *Config) {},
+			wantLevel: "debug",
+			wantPort:  9779,
+		},
+		{
+			name:      "invalid result stores nothing",
+			edit:      func(cfg *Config) { cfg.Logging.Level = "loud" },
+			wantErr:   true,
+			wantLevel: "debug",
+			wantPort:  9779,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, notified := newLive(t)
+			next := nextConfig(t, EnvDevelopment, tt.edit)
+
+			applied, refused, err := l.Reload(next)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reload error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) {
+				t.Errorf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+			if !reflect.DeepEqual(refused, tt.wantRefused) {
+				t.Errorf("refused = %v, want %v", refused, tt.wantRefused)
+			}
+			if *notified != tt.wantNotified {
+				t.Errorf("subscribers notified %d times, want %d", *notified, tt.wantNotified)
+			}
+
+			got := l.Get()
+			if got.Logging.Level != tt.wantLevel || got.Server.Port != tt.wantPort {
+				t.Errorf("running level %q, port %d; want %q, %d", got.Logging.Level, got.Server.Port, tt.wantLevel, tt.wantPort)
+			}
+			// A refused setting keeps the source of its running value
+			for _, src := range got.Sources() {
+				if src.Setting.Env == "SERVER_PORT" && src.Source != SourceDefault {
+					t.Errorf("SERVER_PORT source = %s, want %s", src.Source, SourceDefault)
+				}
+			}
+		})
+	}
+}
+
+func TestLiveReloadRejectsEnvironmentChange(t *testing.T) {
+	l, notified := newLive(t)
+	running := l.Get()
+
+	next := nextConfig(t, EnvStaging, func(cfg *Config) {})
+	if _, _, err := l.Reload(next); err == nil {
+		t.Fatal("Reload succeeded, want an environment change error")
+	}
+	if l.Get() != running || *notified != 0 {
+		t.Error("environment change was applied")
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Setting is a single configuration field declared on Config, bound to the
+// field of a particular Config value.
+type Setting struct {
+	Env         string // Environment variable name
+	Key         string // Config file key, e.g. "server.port"
+	Path        string // Go field path, e.g. "Server.Port"
+	Flag        string // Command-line flag name, e.g. "log-level"
+	Description string
+	Secret      bool
+	Reloadable  bool // Can be applied to a running server by Live.Reload
+
+	field reflect.StructField
+	value reflect.Value
+}
+
+// Settings returns every setting declared on Config, in declaration order,
+// bound to the fields of cfg.
+func Settings(cfg *Config) []Setting {
+	var settings []Setting
+	collectSettings(reflect.ValueOf(cfg).Elem(), "", "", &settings)
+	return settings
+}
+
+// collectSettings walks nested config structs, recording fields that carry an env tag.
+func collectSettings(v reflect.Value, prefix, keyPrefix string, settings *[]Setting) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := prefix + field.Name
+		key := keyPrefix + snakeCase(field.Name)
+
+		env, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				collectSettings(v.Field(i), path+".", key+".", settings)
+			}
+			continue
+		}
+
+		flag := field.Tag.Get("flag")
+		if flag == "" {
+			flag = strings.ReplaceAll(strings.ToLower(env), "_", "-")
+		}
+
+		*settings = append(*settings, Setting{
+			Env:         env,
+			Key:         key,
+			Path:        path,
+			Flag:        flag,
+			Description: field.Tag.Get("desc"),
+			Secret:      field.Tag.Get("secret") == "true",
+			Reloadable:  field.Tag.Get("reload") == "true",
+			field:       field,
+			value:       v.Field(i),
+		})
+	}
+}
+
+// Default returns the declared default for env: the environment-specific tag
+// if present, otherwise the default tag.
+func (s Setting) Default(env Environment) (string, bool) {
+	if value, ok := s.field.Tag.Lookup(string(env)); ok {
+		return value, true
+	}
+	return s.field.Tag.Lookup("default")
+}
+
+// RequiredIn reports whether the setting must have a value in env.
+func (s Setting) RequiredIn(env Environment) bool {
+	required := s.field.Tag.Get("required")
+	if required == "true" {
+		return true
+	}
+	for _, e := range splitList(required) {
+		if Environment(e) == env {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZero reports whether the setting currently holds its type's zero value.
+func (s Setting) IsZero() bool {
+	return s.value.IsZero() || (s.value.Kind() == reflect.Slice || s.value.Kind() == reflect.Map) && s.value.Len() == 0
+}
+
+// Type returns a short name for the setting's value type, for help output.
+func (s Setting) Type() string {
+	switch {
+	case s.value.Type() == durationType:
+		return "duration"
+	case s.value.Kind() == reflect.Slice:
+		return "list"
+	case s.value.Kind() == reflect.Map:
+		return "map"
+	default:
+		return s.value.Kind().String()
+	}
+}
+
+// Set parses raw according to the field's type and assigns it.
+func (s Setting) Set(raw string) error {
+	v := s.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s, 15m or 24h")
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be a boolean (true or false)")
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		items := splitList(raw)
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		v.Set(list)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && v.Type().Elem().Kind() == reflect.String:
+		m, err := parseMap(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// String formats the current value in the same syntax accepted by Set.
+// Secret settings with a value are redacted; use Reveal for the real value.
+func (s Setting) String() string {
+	if s.Secret && !s.IsZero() {
+		return redacted
+	}
+	return s.Reveal()
+}
+
+// Reveal formats the current value like String, without redacting secrets.
+func (s Setting) Reveal() string {
+	v := s.value
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.String:
+		return v.String()
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	case v.Kind() == reflect.Map:
+		return formatMap(v.Interface().(map[string]string))
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Value returns the current value of the setting.
+func (s Setting) Value() any {
+	return s.value.Interface()
+}
+
+// snakeCase converts a Go field name to a config file key, e.g. SSLMode to ssl_mode.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// parseMap parses comma-separated key=value pairs.
+func parseMap(raw string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, pair := range splitList(raw) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("must be comma-separated key=value pairs")
+		}
+		m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return m, nil
+}
+
+// formatMap formats a map as sorted, comma-separated key=value pairs.
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ",")
+}
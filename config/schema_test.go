@@ -0,0 +1,81 @@
+package config
+
+import "testing"
+
+// setting returns the setting bound to cfg with the given env name.
+func setting(t *testing.T, cfg *Config, env string) Setting {
+	t.Helper()
+	for _, s := range Settings(cfg) {
+		if s.Env == env {
+			return s
+		}
+	}
+	t.Fatalf("no setting %s", env)
+	return Setting{}
+}
+
+func TestSettingSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		raw     string
+		want    string // Reveal after Set
+		wantErr bool
+	}{
+		{"string", "SERVER_HOST", "0.0.0.0", "0.0.0.0", false},
+		{"string keeps spaces", "SESSION_COOKIE_NAME", " sid ", " sid ", false},
+		{"int", "SERVER_PORT", " 8080 ", "8080", false},
+		{"int invalid", "SERVER_PORT", "80x", "", true},
+		{"bool", "SESSION_SECURE", "true", "true", false},
+		{"bool numeric", "SESSION_SECURE", "0", "false", false},
+		{"bool invalid", "SESSION_SECURE", "yes", "", true},
+		{"duration", "SESSION_MAX_AGE", "90m", "1h30m0s", false},
+		{"duration without unit", "SESSION_MAX_AGE", "90", "", true},
+		{"secret", "DB_PASSWORD", "p@ss word", "p@ss word", false},
+		{"secret list", "SESSION_SECRET_KEYS", "new, old,,", "new,old", false},
+		{"map", "DB_OPTIONS", "b=2, a = 1", "a=1,b=2", false},
+		{"map empty value", "DB_OPTIONS", "a=", "a=", false},
+		{"map without value", "DB_OPTIONS", "a", "", true},
+		{"map without key", "DB_OPTIONS", "=1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := setting(t, &Config{}, tt.env)
+			err := s.Set(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := s.Reveal(); got != tt.want {
+				t.Errorf("Reveal() = %q, want %q", got, tt.want)
+			}
+			// Reveal must produce a value Set accepts unchanged
+			if err := s.Set(s.Reveal()); err != nil || s.Reveal() != tt.want {
+				t.Errorf("round trip: Reveal() = %q, error %v; want %q", s.Reveal(), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		env   Environment
+		level string
+		port  int
+	}{
+		{EnvDevelopment, "debug", 9779},
+		{EnvStaging, "info", 8080},
+		{EnvProduction, "info", 8080},
+	}
+	for _, tt := range tests {
+		cfg, err := Defaults(tt.env)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.env, err)
+		}
+		if cfg.Logging.Level != tt.level || cfg.Server.Port != tt.port {
+			t.Errorf("%s: level %q, port %d; want %q, %d", tt.env, cfg.Logging.Level, cfg.Server.Port, tt.level, tt.port)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret holds a sensitive configuration value. It redacts itself when
+// printed, logged or marshalled to JSON; call Reveal to get the real value.
+type Secret string
+
+// Reveal returns the secret's actual value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted too
+func (s Secret) GoString() string {
+	return `config.Secret("` + s.String() + `")`
+}
+
+// LogValue implements slog.LogValuer
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalJSON implements json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalText implements encoding.TextMarshaler, used by TOML and map keys
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// RevealAll returns the actual values of a list of secrets
+func RevealAll(secrets []Secret) []string {
+	values := make([]string, len(secrets))
+	for i, s := range secrets {
+		values[i] = s.Reveal()
+	}
+	return values
+}
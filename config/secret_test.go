@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSecret = "hunter2-very-secret"
+
+func TestSecretRedaction(t *testing.T) {
+	s := Secret(testSecret)
+
+	var logged bytes.Buffer
+	slog.New(slog.NewTextHandler(&logged, nil)).Info("connecting", "password", s)
+	jsonValue, err := json.Marshal(struct{ Password Secret }{s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := s.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{"%v", fmt.Sprintf("%v", s)},
+		{"%s", fmt.Sprintf("%s", s)},
+		{"%#v", fmt.Sprintf("%#v", s)},
+		{"%+v struct", fmt.Sprintf("%+v", struct{ Password Secret }{s})},
+		{"slog", logged.String()},
+		{"json", string(jsonValue)},
+		{"text", string(text)},
+		{"setting", func() string {
+			cfg := &Config{Database: DatabaseConfig{Password: s}}
+			return setting(t, cfg, "DB_PASSWORD").String()
+		}()},
+		{"setting list", func() string {
+			cfg := &Config{Session: SessionConfig{SecretKeys: []Secret{s}}}
+			return setting(t, cfg, "SESSION_SECRET_KEYS").String()
+		}()},
+	}
+	for _, tt := range tests {
+		if strings.Contains(tt.got, testSecret) {
+			t.Errorf("%s: %q reveals the secret", tt.name, tt.got)
+		}
+		if !strings.Contains(tt.got, redacted) {
+			t.Errorf("%s: %q is not redacted", tt.name, tt.got)
+		}
+	}
+
+	if s.Reveal() != testSecret {
+		t.Errorf("Reveal() = %q, want %q", s.Reveal(), testSecret)
+	}
+	if got := Secret("").String(); got != "" {
+		t.Errorf("empty secret prints %q, want it empty", got)
+	}
+}
+
+func TestConfigOutputRedactsSecrets(t *testing.T) {
+	cfg, err := Defaults(EnvDevelopment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Database.Driver = "postgres"
+	cfg.Database.User = "app"
+	cfg.Database.Password = testSecret
+	cfg.Session.SecretKeys = []Secret{testSecret}
+
+	var sources bytes.Buffer
+	if err := cfg.WriteSources(&sources); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string]string{
+		"sources":      sources.String(),
+		"database url": cfg.RedactedDatabaseURL(),
+		"%+v":          fmt.Sprintf("%+v", cfg),
+	} {
+		if strings.Contains(got, testSecret) {
+			t.Errorf("%s reveals the secret:\n%s", name, got)
+		}
+	}
+	if !strings.Contains(cfg.GetDatabaseURL().Reveal(), testSecret) {
+		t.Error("GetDatabaseURL().Reveal() is missing the password")
+	}
+}
+
+func TestReadSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte(testSecret+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("trims trailing newline", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD_FILE", path)
+		key, value, err := readSecretFile("DB_PASSWORD")
+		if err != nil || key != "DB_PASSWORD_FILE" || value != testSecret {
+			t.Errorf("got %q, %q, %v; want DB_PASSWORD_FILE, %q", key, value, err, testSecret)
+		}
+	})
+	t.Run("conflicts with the variable", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD_FILE", path)
+		t.Setenv("DB_PASSWORD", "other")
+		if _, _, err := readSecretFile("DB_PASSWORD"); err == nil {
+			t.Error("want an error when both are set")
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD_FILE", path+".missing")
+		if _, _, err := readSecretFile("DB_PASSWORD"); err == nil {
+			t.Error("want an error for a missing file")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Source identifies the configuration layer that supplied a value. Layers in
+// increasing precedence: default, config file, .env file, process environment,
+// command-line flag.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceDotEnv  Source = ".env"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// SettingSource records where a setting's final value came from.
+type SettingSource struct {
+	Setting Setting
+	Source  Source
+}
+
+// Sources returns every setting with the layer that supplied its final value.
+func (c *Config) Sources() []SettingSource {
+	settings := Settings(c)
+	sources := make([]SettingSource, 0, len(settings))
+	for _, s := range settings {
+		source, ok := c.sources[s.Env]
+		if !ok {
+			source = SourceDefault
+		}
+		sources = append(sources, SettingSource{Setting: s, Source: source})
+	}
+	return sources
+}
+
+// WriteSources writes a table of every setting, its value and the layer that
+// supplied it. Secret values are redacted.
+func (c *Config) WriteSources(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tSOURCE")
+	for _, src := range c.Sources() {
+		value := src.Setting.String()
+		source := string(src.Source)
+		if src.Source == SourceFile && c.configFile != "" {
+			source += " (" + c.configFile + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", src.Setting.Env, value, source)
+	}
+	return tw.Flush()
+}
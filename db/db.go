@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+// Open opens a connection pool for the configured database, applies the pool
+// settings and checks the database is reachable within ConnectTimeout. The
+// caller must Close the returned pool.
+func Open(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open(cfg.Database.Driver, cfg.GetDatabaseURL().Reveal())
+	if err != nil {
+		return nil, fmt.Errorf("opening %s database: %w", cfg.Database.Driver, err)
+	}
+
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Database.ConnectTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connecting to %s: %w", cfg.RedactedDatabaseURL(), err)
+	}
+	return db, nil
+}
+
+// Rebind rewrites a query written with ? placeholders into the driver's
+// placeholder syntax: $1, $2, ... for postgres. The query must not contain
+// a literal question mark.
+func Rebind(driver, query string) string {
+	if driver != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+func TestOpenAppliesPoolSettings(t *testing.T) {
+	database, cfg := openTestDatabase(t)
+	if got := database.Stats().MaxOpenConnections; got != cfg.Database.MaxOpenConns {
+		t.Errorf("MaxOpenConnections = %d, want %d", got, cfg.Database.MaxOpenConns)
+	}
+
+	cfg.Database.MaxOpenConns = 3
+	other, err := Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if got := other.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("MaxOpenConnections = %d, want 3", got)
+	}
+
+	// The sqlite pragmas from the DSN apply to every connection
+	var foreignKeys int
+	if err := other.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil || foreignKeys != 1 {
+		t.Errorf("foreign_keys = %d, %v; want 1", foreignKeys, err)
+	}
+}
+
+func TestOpenFailsWhenUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(cfg *config.Config)
+	}{
+		{"sqlite in missing directory", func(cfg *config.Config) {
+			cfg.Database.Name = filepath.Join(t.TempDir(), "missing", "test.db")
+		}},
+		{"postgres refusing connections", func(cfg *config.Config) {
+			cfg.Database.Driver = "postgres"
+			cfg.Database.Host = "127.0.0.1"
+			cfg.Database.Port = 1
+			cfg.Database.User = "app"
+			cfg.Database.Password = "hunter2-secret"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Defaults(config.EnvDevelopment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Database.ConnectTimeout = 2 * time.Second
+			tt.edit(cfg)
+
+			database, err := Open(context.Background(), cfg)
+			if err == nil {
+				database.Close()
+				t.Fatal("Open succeeded, want a connection error")
+			}
+			if !strings.Contains(err.Error(), "connecting to") {
+				t.Errorf("error = %v, want a connection error", err)
+			}
+			if strings.Contains(err.Error(), "hunter2-secret") {
+				t.Errorf("error %q reveals the password", err)
+			}
+		})
+	}
+}
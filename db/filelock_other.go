@@ -0,0 +1,41 @@
+//go:build !unix
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// lockFile creates path exclusively, waiting until it can or ctx is done,
+// and removes it on release. A lock file left by a crashed process must be
+// removed by hand.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for %s, remove it if no migration is running: %w", path, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	return func() {
+		if err := os.Remove(path); err != nil {
+			slog.Error("failed to release migration lock", "path", path, "error", err)
+		}
+	}, nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// Names of the migration lock: an advisory lock key in postgres and a named lock in mysql
+const (
+	migrationLockKey  = 7_262_687_163
+	migrationLockName = "htmx_quickstart_migrations"
+)
+
+// lock acquires the migration lock and returns the function that releases
+// it: a session-level advisory lock held by conn for postgres, a named lock
+// for mysql, and an exclusive lock on a file beside the database for sqlite3.
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	switch m.driver {
+	case "postgres":
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			return nil, err
+		}
+		return func() {
+			if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+				slog.Error("failed to release migration lock", "error", err)
+			}
+		}, nil
+	case "mysql":
+		var acquired sql.NullInt64
+		if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, -1)`, migrationLockName).Scan(&acquired); err != nil {
+			return nil, err
+		}
+		if acquired.Int64 != 1 {
+			return nil, fmt.Errorf("GET_LOCK('%s') failed", migrationLockName)
+		}
+		return func() {
+			if _, err := conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, migrationLockName); err != nil {
+				slog.Error("failed to release migration lock", "error", err)
+			}
+		}, nil
+	default:
+		if m.lockPath == "" {
+			return func() {}, nil
+		}
+		return lockFile(ctx, m.lockPath)
+	}
+}
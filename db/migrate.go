@@ -0,0 +1,377 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+// migrationsTable records the version of every applied migration
+const migrationsTable = "schema_migrations"
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change read from a pair of SQL files.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // Empty if the migration cannot be rolled back
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // Applied to the database but no longer in the migration files
+}
+
+// Migrator applies and rolls back migrations. Every operation holds a
+// database-wide lock, so concurrent instances cannot migrate at once.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	lockPath   string // Lock file for sqlite3, empty for an in-memory database
+	migrations []Migration
+}
+
+// NewMigrator reads the migrations in fsys for the configured database.
+func NewMigrator(db *sql.DB, cfg *config.Config, fsys fs.FS) (*Migrator, error) {
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{db: db, driver: cfg.Database.Driver, migrations: migrations}
+	if m.driver == "sqlite3" && cfg.Database.Name != ":memory:" {
+		m.lockPath = cfg.Database.Name + ".migrate.lock"
+	}
+	return m, nil
+}
+
+// readMigrations parses and orders the migration files in fsys.
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name '%s', must be <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in '%s': %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both '%s' and '%s'", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the n most recently applied migrations and returns how many were rolled back.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("migrate: down steps must be at least 1, got %d", n)
+	}
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		latest, err := m.latestApplied(ctx, conn, n)
+		if err != nil {
+			return err
+		}
+		for _, migration := range latest {
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		latest, err := m.latestApplied(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+		if len(latest) == 0 {
+			return errors.New("no applied migration to redo")
+		}
+		if err := m.apply(ctx, conn, latest[0], false); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, latest[0], true)
+	})
+}
+
+// Status lists every known migration, and any applied version whose files
+// are missing, in version order.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			appliedAt, ok := done[migration.Version]
+			statuses = append(statuses, MigrationStatus{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+			delete(done, migration.Version)
+		}
+		for version, appliedAt := range done {
+			statuses = append(statuses, MigrationStatus{Version: version, Applied: true, AppliedAt: appliedAt, Missing: true})
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// withLock runs fn on a dedicated connection while holding the migration
+// lock, after making sure the migrations table exists.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("migrate: acquiring lock: %w", err)
+	}
+	defer unlock()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("migrate: creating %s: %w", migrationsTable, err)
+	}
+	return fn(conn)
+}
+
+// appliedVersions returns when each applied version was applied.
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM `+migrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: reading %s: %w", migrationsTable, err)
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("migrate: reading %s: %w", migrationsTable, err)
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+// latestApplied returns up to n applied migrations, newest first.
+func (m *Migrator) latestApplied(ctx context.Context, conn *sql.Conn, n int) ([]Migration, error) {
+	done, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(done))
+	for version := range done {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var latest []Migration
+	for _, version := range versions[:min(n, len(versions))] {
+		migration, ok := m.find(version)
+		if !ok {
+			return nil, fmt.Errorf("migration %d is applied but its files are missing", version)
+		}
+		latest = append(latest, migration)
+	}
+	return latest, nil
+}
+
+// find returns the migration with the given version.
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// apply runs a migration's up or down SQL and records the result in a
+// single transaction. MySQL commits DDL implicitly, so a failed MySQL
+// migration may be partially applied.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	direction, body := "up", migration.Up
+	if !up {
+		direction, body = "down", migration.Down
+		if strings.TrimSpace(body) == "" {
+			return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements(body) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, Rebind(m.driver, `INSERT INTO `+migrationsTable+` (version, name, applied_at) VALUES (?, ?, ?)`),
+			migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, Rebind(m.driver, `DELETE FROM `+migrationsTable+` WHERE version = ?`), migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: recording version: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	slog.Info("migration "+direction, "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+// statements splits a migration for execution. The postgres and sqlite3
+// drivers run several statements in one call; the mysql driver does not
+// unless multiStatements is enabled, so its migrations are split on
+// semicolons outside quotes and comments.
+func (m *Migrator) statements(body string) []string {
+	if m.driver != "mysql" {
+		return []string{body}
+	}
+
+	var stmts []string
+	var b strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(b.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		b.Reset()
+	}
+
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(body) {
+				b.WriteByte(c)
+				i++
+				c = body[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && strings.HasPrefix(body[i:], "--"), c == '#':
+			end := strings.IndexByte(body[i:], '\n')
+			if end < 0 {
+				end = len(body) - i
+			}
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(body[i:], "/*"):
+			end := strings.Index(body[i+2:], "*/")
+			if end < 0 {
+				end = len(body) - i - 2
+			}
+			i += end + 3
+			continue
+		case c == ';':
+			flush()
+			continue
+		}
+		b.WriteByte(c)
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+func TestMySQLStatements(t *testing.T) {
+	m := &Migrator{driver: "mysql"}
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "single statement",
+			body: "CREATE TABLE a (id INT)",
+			want: []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name: "several statements",
+			body: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want: []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name: "empty statements",
+			body: ";;\n  ;CREATE TABLE a (id INT);  \n",
+			want: []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name: "semicolon in single quotes",
+			body: "INSERT INTO a VALUES ('x;y'); SELECT 1",
+			want: []string{"INSERT INTO a VALUES ('x;y')", "SELECT 1"},
+		},
+		{
+			name: "semicolon in double quotes",
+			body: `INSERT INTO a VALUES ("x;y"); SELECT 1`,
+			want: []string{`INSERT INTO a VALUES ("x;y")`, "SELECT 1"},
+		},
+		{
+			name: "semicolon in backticks",
+			body: "CREATE TABLE `a;b` (id INT); SELECT 1",
+			want: []string{"CREATE TABLE `a;b` (id INT)", "SELECT 1"},
+		},
+		{
+			name: "escaped quote",
+			body: `INSERT INTO a VALUES ('it\'s; here'); SELECT 1`,
+			want: []string{`INSERT INTO a VALUES ('it\'s; here')`, "SELECT 1"},
+		},
+		{
+			name: "doubled quote",
+			body: "INSERT INTO a VALUES ('it''s; here'); SELECT 1",
+			want: []string{"INSERT INTO a VALUES ('it''s; here')", "SELECT 1"},
+		},
+		{
+			name: "other quote inside string",
+			body: `INSERT INTO a VALUES ('say "hi;"'); SELECT 1`,
+			want: []string{`INSERT INTO a VALUES ('say "hi;"')`, "SELECT 1"},
+		},
+		{
+			name: "dash comment",
+			body: "-- create a; then b\nCREATE TABLE a (id INT); -- trailing; comment\nSELECT 1",
+			want: []string{"CREATE TABLE a (id INT)", "SELECT 1"},
+		},
+		{
+			name: "hash comment",
+			body: "# create a; then b\nCREATE TABLE a (id INT);\nSELECT 1 # done;",
+			want: []string{"CREATE TABLE a (id INT)", "SELECT 1"},
+		},
+		{
+			name: "block comment",
+			body: "/* create a;\n then b */CREATE TABLE a (id INT);SELECT /* ; */ 1",
+			want: []string{"CREATE TABLE a (id INT)", "SELECT  1"},
+		},
+		{
+			name: "comment markers in strings",
+			body: "INSERT INTO a VALUES ('-- x; # y; /* z; */'); SELECT 1",
+			want: []string{"INSERT INTO a VALUES ('-- x; # y; /* z; */')", "SELECT 1"},
+		},
+		{
+			name: "comment only",
+			body: "-- nothing to do\n/* still; nothing */",
+			want: nil,
+		},
+		{
+			name: "unterminated block comment",
+			body: "SELECT 1; /* never; closed",
+			want: []string{"SELECT 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.statements(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements(%q)\n got %q\nwant %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementsUnsplitForOtherDrivers(t *testing.T) {
+	body := "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n"
+	for _, driver := range []string{"postgres", "sqlite3"} {
+		m := &Migrator{driver: driver}
+		if got := m.statements(body); !reflect.DeepEqual(got, []string{body}) {
+			t.Errorf("%s: statements = %q, want the body unchanged", driver, got)
+		}
+	}
+}
+
+func TestDownRejectsInvalidSteps(t *testing.T) {
+	m := &Migrator{driver: "sqlite3"}
+	for _, n := range []int{0, -1} {
+		if _, err := m.Down(context.Background(), n); err == nil {
+			t.Errorf("Down(%d) succeeded, want an error", n)
+		}
+	}
+}
+
+// testMigrations creates two tables, the second of which cannot be rolled back.
+var testMigrations = fstest.MapFS{
+	"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+	"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);")},
+	"0002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	"0003_create_c.up.sql":   {Data: []byte("CREATE TABLE c (id INTEGER);")},
+}
+
+// openTestDatabase opens a sqlite database in a temporary directory.
+func openTestDatabase(t *testing.T) (*sql.DB, *config.Config) {
+	t.Helper()
+	cfg, err := config.Defaults(config.EnvDevelopment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Database.Name = filepath.Join(t.TempDir(), "test.db")
+
+	database, err := Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database, cfg
+}
+
+// newTestMigrator creates a migrator for the database over fsys.
+func newTestMigrator(t *testing.T, database *sql.DB, cfg *config.Config, fsys fs.FS) *Migrator {
+	t.Helper()
+	m, err := NewMigrator(database, cfg, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// tables returns which of the test migration tables exist.
+func tables(t *testing.T, database *sql.DB) string {
+	t.Helper()
+	var got []string
+	for _, name := range []string{"a", "b", "c"} {
+		var n int
+		err := database.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 0 {
+			got = append(got, name)
+		}
+	}
+	return strings.Join(got, ",")
+}
+
+// applied returns the versions Status reports as applied, marking missing ones.
+func applied(t *testing.T, m *Migrator) string {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range statuses {
+		switch {
+		case s.Missing:
+			got = append(got, fmt.Sprintf("%d(missing)", s.Version))
+		case s.Applied:
+			got = append(got, fmt.Sprint(s.Version))
+		}
+	}
+	return strings.Join(got, ",")
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	database, cfg := openTestDatabase(t)
+	m := newTestMigrator(t, database, cfg, testMigrations)
+
+	if n, err := m.Up(ctx); err != nil || n != 3 {
+		t.Fatalf("Up = %d, %v; want 3 applied", n, err)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 0 {
+		t.Fatalf("second Up = %d, %v; want nothing to apply", n, err)
+	}
+	if got := tables(t, database); got != "a,b,c" {
+		t.Errorf("tables after Up = %q, want a,b,c", got)
+	}
+
+	// 0003 has no down file, so nothing is rolled back
+	if n, err := m.Down(ctx, 2); err == nil || n != 0 {
+		t.Fatalf("Down(2) = %d, %v; want an error for the missing down file", n, err)
+	}
+	if got := applied(t, m); got != "1,2,3" {
+		t.Errorf("applied after failed Down = %q, want 1,2,3", got)
+	}
+
+	// Once 0003's files are gone it is reported missing and blocks rollback
+	without3 := fstest.MapFS{}
+	for name, file := range testMigrations {
+		if !strings.HasPrefix(name, "0003") {
+			without3[name] = file
+		}
+	}
+	m = newTestMigrator(t, database, cfg, without3)
+	if got := applied(t, m); got != "1,2,3(missing)" {
+		t.Errorf("applied without 0003 files = %q, want 1,2,3(missing)", got)
+	}
+	if _, err := m.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), "files are missing") {
+		t.Errorf("Down with missing files error = %v, want a missing files error", err)
+	}
+
+	if _, err := database.Exec(`DROP TABLE c; DELETE FROM schema_migrations WHERE version = 3`); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Redo(ctx); err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if got := applied(t, m); got != "1,2" {
+		t.Errorf("applied after Redo = %q, want 1,2", got)
+	}
+	if n, err := m.Down(ctx, 5); err != nil || n != 2 {
+		t.Fatalf("Down(5) = %d, %v; want 2 rolled back", n, err)
+	}
+	if got := tables(t, database); got != "" {
+		t.Errorf("tables after Down = %q, want none", got)
+	}
+	if got := applied(t, m); got != "" {
+		t.Errorf("applied after Down = %q, want none", got)
+	}
+}
+
+func TestMigratorWaitsForLock(t *testing.T) {
+	database, cfg := openTestDatabase(t)
+	m := newTestMigrator(t, database, cfg, testMigrations)
+
+	unlock, err := lockFile(context.Background(), m.lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := m.Up(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Up while locked = %v, want context.DeadlineExceeded", err)
+	}
+	if got := tables(t, database); got != "" {
+		t.Errorf("tables created while locked: %q", got)
+	}
+
+	unlock()
+	if n, err := m.Up(context.Background()); err != nil || n != 3 {
+		t.Errorf("Up after unlock = %d, %v; want 3 applied", n, err)
+	}
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the application's SQL migrations, embedded from
+// db/migrations. Files are named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, e.g. 0002_add_user_roles.up.sql.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err) // The directory is embedded at build time
+	}
+	return sub
+}
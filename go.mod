@@ -7,3 +7,13 @@ require github.com/a-h/templ v0.3.906
 require github.com/google/uuid v1.6.0
 
 require github.com/joho/godotenv v1.5.1
+
+require github.com/BurntSushi/toml v1.5.0
+
+require github.com/lib/pq v1.10.9
+
+require github.com/go-sql-driver/mysql v1.9.3
+
+require github.com/mattn/go-sqlite3 v1.14.32
+
+require filippo.io/edwards25519 v1.1.0 // indirect
@@ -7,6 +7,7 @@ import (
 
 	"seesharpsi/htmx_quickstart/logger"
 	"seesharpsi/htmx_quickstart/services"
+	"seesharpsi/htmx_quickstart/session"
 	"seesharpsi/htmx_quickstart/templ"
 )
 
@@ -28,7 +29,9 @@ func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
 
 	// Get or create session
 	sess, cookie := h.Service.GetOrCreateSession(r)
-	http.SetCookie(w, &cookie)
+	if cookie.Name != "" {
+		http.SetCookie(w, &cookie)
+	}
 
 	// Update page data with session info
 	if sess != nil {
@@ -36,8 +39,13 @@ func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
 		pageData.IsLoggedIn = true
 	}
 
+	flashes, err := h.Service.PopFlashes(sess)
+	if err != nil {
+		slog.Error("failed to load flash messages", "error", err, "request_id", requestID)
+	}
+
 	// Render template
-	if err := templ.Index().Render(r.Context(), w); err != nil {
+	if err := templ.Index(flashes).Render(r.Context(), w); err != nil {
 		slog.Error("failed to render index template", "error", err, "request_id", requestID)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
@@ -58,7 +66,9 @@ func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
 
 	// Get or create session
 	sess, cookie := h.Service.GetOrCreateSession(r)
-	http.SetCookie(w, &cookie)
+	if cookie.Name != "" {
+		http.SetCookie(w, &cookie)
+	}
 
 	// Update page data with session info
 	if sess != nil {
@@ -66,12 +76,16 @@ func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
 		pageData.IsLoggedIn = true
 	}
 
+	flashes := h.popFlashesOOB(r, sess)
+
 	// Render template
 	if err := templ.Test().Render(r.Context(), w); err != nil {
 		slog.Error("failed to render test template", "error", err, "request_id", requestID)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+
+	h.renderFlashesOOB(w, r, flashes)
 }
 
 func (h *Handler) NotFound(w http.ResponseWriter, r *http.Request) {
@@ -88,7 +102,9 @@ func (h *Handler) NotFound(w http.ResponseWriter, r *http.Request) {
 
 	// Get or create session
 	sess, cookie := h.Service.GetOrCreateSession(r)
-	http.SetCookie(w, &cookie)
+	if cookie.Name != "" {
+		http.SetCookie(w, &cookie)
+	}
 
 	// Update page data with session info
 	if sess != nil {
@@ -104,12 +120,51 @@ func (h *Handler) NotFound(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// popFlashesOOB returns pending flash messages for an HTMX partial response.
+// Full page requests leave them for the layout to render. Call it before the
+// response is written so cookie-backed sessions can persist the change.
+func (h *Handler) popFlashesOOB(r *http.Request, sess *session.Session) []session.Flash {
+	if !isHTMXRequest(r) {
+		return nil
+	}
+
+	flashes, err := h.Service.PopFlashes(sess)
+	if err != nil {
+		requestID := logger.RequestIDFromContext(r.Context())
+		slog.Error("failed to load flash messages", "error", err, "request_id", requestID)
+	}
+	return flashes
+}
+
+// renderFlashesOOB appends flash messages to a partial response as an
+// out-of-band swap
+func (h *Handler) renderFlashesOOB(w http.ResponseWriter, r *http.Request, flashes []session.Flash) {
+	if len(flashes) == 0 {
+		return
+	}
+
+	if err := templ.Flashes(flashes, true).Render(r.Context(), w); err != nil {
+		requestID := logger.RequestIDFromContext(r.Context())
+		slog.Error("failed to render flash messages", "error", err, "request_id", requestID)
+	}
+}
+
+// isHTMXRequest reports whether the request was issued by HTMX
+func isHTMXRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	requestID := logger.RequestIDFromContext(r.Context())
 	slog.Debug("handling health check", "request_id", requestID)
 
 	// Return simple JSON response
 	w.Header().Set("Content-Type", "application/json")
+	if err := h.Service.CheckHealth(r.Context()); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"unhealthy","timestamp":"` + time.Now().Format(time.RFC3339) + `"}`))
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"healthy","timestamp":"` + time.Now().Format(time.RFC3339) + `"}`))
 }
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// root is the handler built from the current logging configuration
+var root atomic.Pointer[slog.Handler]
+
+// reloadableHandler forwards records to the current root handler, so loggers
+// created before a reload follow the new level and format.
+type reloadableHandler struct {
+	wrap []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, in order
+}
+
+func (h *reloadableHandler) handler() slog.Handler {
+	handler := *root.Load()
+	for _, wrap := range h.wrap {
+		handler = wrap(handler)
+	}
+	return handler
+}
+
+func (h *reloadableHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return (*root.Load()).Enabled(ctx, level)
+}
+
+func (h *reloadableHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+func (h *reloadableHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *reloadableHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *reloadableHandler) with(wrap func(slog.Handler) slog.Handler) *reloadableHandler {
+	return &reloadableHandler{wrap: append(h.wrap[:len(h.wrap):len(h.wrap)], wrap)}
+}
@@ -28,7 +28,9 @@ func ContextWithRequestID(ctx context.Context, requestID string) context.Context
 	return context.WithValue(ctx, requestIDKey, requestID)
 }
 
-// SetupLogger initializes structured logging based on configuration
+// SetupLogger initializes structured logging based on configuration. Calling
+// it again, e.g. after a config reload, switches every logger it returned to
+// the new level and format.
 func SetupLogger(cfg *config.Config) *slog.Logger {
 	var handler slog.Handler
 
@@ -45,7 +47,8 @@ func SetupLogger(cfg *config.Config) *slog.Logger {
 		handler = slog.NewJSONHandler(os.Stdout, opts)
 	}
 
-	logger := slog.New(handler)
+	root.Store(&handler)
+	logger := slog.New(&reloadableHandler{})
 	slog.SetDefault(logger)
 	return logger
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"seesharpsi/htmx_quickstart/config"
+	"seesharpsi/htmx_quickstart/db"
+	"seesharpsi/htmx_quickstart/logger"
+)
+
+// runMigrateCommand handles "migrate <up|down [N]|redo|status>" and returns the process exit code
+func runMigrateCommand(args []string) int {
+	if len(args) == 0 {
+		migrateUsage()
+		return 2
+	}
+	command := args[0]
+	switch command {
+	case "up", "down", "redo", "status":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n", command)
+		migrateUsage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("htmx_quickstart migrate "+command, flag.ContinueOnError)
+	flags := config.RegisterFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	steps := 1
+	if command == "down" && fs.NArg() > 0 {
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "invalid number of migrations %q, must be a positive integer\n", fs.Arg(0))
+			return 2
+		}
+		steps = n
+		// Flags may also follow N, e.g. "down 2 --env production"
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected argument %q\n", fs.Arg(0))
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(flags.Options())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	logger.SetupLogger(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	database, err := db.Open(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database, cfg, db.Migrations())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch command {
+	case "up":
+		var applied int
+		if applied, err = migrator.Up(ctx); err == nil {
+			fmt.Printf("applied %d migration(s)\n", applied)
+		}
+	case "down":
+		var rolledBack int
+		if rolledBack, err = migrator.Down(ctx, steps); err == nil {
+			fmt.Printf("rolled back %d migration(s)\n", rolledBack)
+		}
+	case "redo":
+		err = migrator.Redo(ctx)
+	case "status":
+		err = printMigrationStatus(ctx, migrator)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func migrateUsage() {
+	fmt.Fprintln(os.Stderr, "usage: htmx_quickstart migrate <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  up          apply every pending migration")
+	fmt.Fprintln(os.Stderr, "  down [N]    roll back the last N migrations (default 1)")
+	fmt.Fprintln(os.Stderr, "  redo        roll back and reapply the last migration")
+	fmt.Fprintln(os.Stderr, "  status      list migrations and whether they are applied")
+}
+
+// printMigrationStatus writes a table of every migration and when it was applied
+func printMigrationStatus(ctx context.Context, migrator *db.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		status, appliedAt := "pending", ""
+		if s.Applied {
+			status, appliedAt = "applied", s.AppliedAt.Local().Format(time.RFC3339)
+		}
+		if s.Missing {
+			status = "applied, file missing"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"seesharpsi/htmx_quickstart/db"
+)
+
+// ErrNotFound is returned when a repository has no record matching a lookup.
+var ErrNotFound = errors.New("record not found")
+
+// querier is the subset of *sql.DB and *sql.Tx that repositories use, so the
+// same query code runs inside or outside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// Store groups the application's repositories over a single database.
+type Store struct {
+	db     *sql.DB
+	driver string
+
+	Users UserRepository
+}
+
+// NewStore creates the repositories for a database opened with the given driver.
+func NewStore(database *sql.DB, driver string) *Store {
+	s := &Store{db: database, driver: driver}
+	s.Users = &userRepository{store: s}
+	return s
+}
+
+// WithTx runs fn in a transaction carried by the context passed to it, so
+// every repository call made with that context joins the transaction. If
+// ctx already carries a transaction, fn joins it and the outermost WithTx
+// commits. The transaction is rolled back if fn returns an error or panics.
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that the database is reachable.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// conn returns the transaction carried by ctx, or the database outside a transaction.
+func (s *Store) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.db
+}
+
+// rebind converts a query's ? placeholders for the store's driver.
+func (s *Store) rebind(query string) string {
+	return db.Rebind(s.driver, query)
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"seesharpsi/htmx_quickstart/config"
+	"seesharpsi/htmx_quickstart/db"
+)
+
+// newTestStore opens a migrated sqlite database in a temporary directory.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	cfg, err := config.Defaults(config.EnvDevelopment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Database.Name = filepath.Join(t.TempDir(), "test.db")
+
+	ctx := context.Background()
+	database, err := db.Open(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	migrator, err := db.NewMigrator(database, cfg, db.Migrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return NewStore(database, cfg.Database.Driver)
+}
+
+// assertUser checks whether a user with the given ID was committed.
+func assertUser(t *testing.T, s *Store, id string, want bool) {
+	t.Helper()
+	_, err := s.Users.GetByID(context.Background(), id)
+	switch {
+	case want && err != nil:
+		t.Errorf("user %s: %v, want it committed", id, err)
+	case !want && !errors.Is(err, ErrNotFound):
+		t.Errorf("user %s: error = %v, want ErrNotFound", id, err)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name       string
+		fn         func(s *Store, ctx context.Context) error
+		wantErr    error
+		wantOuter  bool
+		wantNested bool
+	}{
+		{
+			name: "commits",
+			fn: func(s *Store, ctx context.Context) error {
+				return s.Users.Create(ctx, &User{ID: "outer", Email: "outer@example.com", Name: "Outer"})
+			},
+			wantOuter: true,
+		},
+		{
+			name: "rolls back on error",
+			fn: func(s *Store, ctx context.Context) error {
+				if err := s.Users.Create(ctx, &User{ID: "outer", Email: "outer@example.com", Name: "Outer"}); err != nil {
+					return err
+				}
+				return errFailed
+			},
+			wantErr: errFailed,
+		},
+		{
+			name: "nested commits with outer",
+			fn: func(s *Store, ctx context.Context) error {
+				if err := s.Users.Create(ctx, &User{ID: "outer", Email: "outer@example.com", Name: "Outer"}); err != nil {
+					return err
+				}
+				return s.WithTx(ctx, func(ctx context.Context) error {
+					return s.Users.Create(ctx, &User{ID: "nested", Email: "nested@example.com", Name: "Nested"})
+				})
+			},
+			wantOuter:  true,
+			wantNested: true,
+		},
+		{
+			name: "nested error rolls back outer",
+			fn: func(s *Store, ctx context.Context) error {
+				if err := s.Users.Create(ctx, &User{ID: "outer", Email: "outer@example.com", Name: "Outer"}); err != nil {
+					return err
+				}
+				return s.WithTx(ctx, func(ctx context.Context) error {
+					if err := s.Users.Create(ctx, &User{ID: "nested", Email: "nested@example.com", Name: "Nested"}); err != nil {
+						return err
+					}
+					return errFailed
+				})
+			},
+			wantErr: errFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			err := s.WithTx(context.Background(), func(ctx context.Context) error {
+				return tt.fn(s, ctx)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTx error = %v, want %v", err, tt.wantErr)
+			}
+			assertUser(t, s, "outer", tt.wantOuter)
+			assertUser(t, s, "nested", tt.wantNested)
+		})
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	s := newTestStore(t)
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the panic to be re-raised", p)
+			}
+		}()
+		s.WithTx(context.Background(), func(ctx context.Context) error {
+			if err := s.Users.Create(ctx, &User{ID: "outer", Email: "outer@example.com", Name: "Outer"}); err != nil {
+				t.Fatal(err)
+			}
+			panic("boom")
+		})
+	}()
+
+	assertUser(t, s, "outer", false)
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is an application user, stored in the users table.
+type User struct {
+	ID        string
+	Email     string
+	Name      string
+	CreatedAt time.Time
+}
+
+// UserRepository persists users.
+type UserRepository interface {
+	Create(ctx context.Context, user *User) error
+	GetByID(ctx context.Context, id string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	List(ctx context.Context, limit, offset int) ([]*User, error)
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id string) error
+}
+
+// userRepository implements UserRepository with SQL shared by every supported driver.
+type userRepository struct {
+	store *Store
+}
+
+const userColumns = "id, email, name, created_at"
+
+// Create inserts a user, assigning its ID and creation time when unset.
+func (r *userRepository) Create(ctx context.Context, user *User) error {
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.store.conn(ctx).ExecContext(ctx,
+		r.store.rebind(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?)`),
+		user.ID, user.Email, user.Name, user.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the user with the given ID, or ErrNotFound.
+func (r *userRepository) GetByID(ctx context.Context, id string) (*User, error) {
+	return r.getOne(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+}
+
+// GetByEmail returns the user with the given email address, or ErrNotFound.
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.getOne(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email)
+}
+
+// List returns users ordered by creation time, oldest first.
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*User, error) {
+	rows, err := r.store.conn(ctx).QueryContext(ctx,
+		r.store.rebind(`SELECT `+userColumns+` FROM users ORDER BY created_at, id LIMIT ? OFFSET ?`),
+		limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("listing users: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
+	}
+	return users, nil
+}
+
+// Update saves a user's email and name, or returns ErrNotFound.
+func (r *userRepository) Update(ctx context.Context, user *User) error {
+	result, err := r.store.conn(ctx).ExecContext(ctx,
+		r.store.rebind(`UPDATE users SET email = ?, name = ? WHERE id = ?`),
+		user.Email, user.Name, user.ID)
+	if err != nil {
+		return fmt.Errorf("updating user: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// Delete removes the user with the given ID, or returns ErrNotFound.
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.store.conn(ctx).ExecContext(ctx, r.store.rebind(`DELETE FROM users WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("deleting user: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// getOne runs a query expected to match at most one user.
+func (r *userRepository) getOne(ctx context.Context, query string, args ...any) (*User, error) {
+	user, err := scanUser(r.store.conn(ctx).QueryRowContext(ctx, r.store.rebind(query), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading user: %w", err)
+	}
+	return user, nil
+}
+
+// scanUser reads a user from a row selected with userColumns.
+func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
+	var user User
+	if err := row.Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// requireAffected reports ErrNotFound when a statement changed no rows.
+func requireAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -10,8 +12,10 @@ import (
 	"syscall"
 
 	"seesharpsi/htmx_quickstart/config"
+	"seesharpsi/htmx_quickstart/db"
 	"seesharpsi/htmx_quickstart/handlers"
 	"seesharpsi/htmx_quickstart/logger"
+	"seesharpsi/htmx_quickstart/repository"
 	"seesharpsi/htmx_quickstart/services"
 	"seesharpsi/htmx_quickstart/session"
 )
@@ -31,9 +35,52 @@ func custom404Handler(mux *http.ServeMux, h *handlers.Handler) http.Handler {
 	})
 }
 
+// reloadConfig re-reads the configuration and applies the settings that can
+// change while running. An invalid configuration is rejected as a whole.
+func reloadConfig(live *config.Live, opts config.Options) {
+	slog.Info("reloading configuration")
+	next, err := config.LoadWithOptions(opts)
+	if err != nil {
+		slog.Error("config reload failed, keeping current configuration", "error", err)
+		return
+	}
+
+	applied, refused, err := live.Reload(next)
+	for _, c := range refused {
+		slog.Warn("setting requires a restart, keeping current value",
+			"setting", c.Setting, "current", c.Old, "requested", c.New)
+	}
+	if err != nil {
+		slog.Error("config reload failed, keeping current configuration", "error", err)
+		return
+	}
+	for _, c := range applied {
+		slog.Info("setting reloaded", "setting", c.Setting, "old", c.Old, "new", c.New)
+	}
+	slog.Info("configuration reloaded", "changed", len(applied), "refused", len(refused))
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrateCommand(os.Args[2:]))
+		}
+	}
+
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	if flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected argument %q\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
+	opts := flags.Options()
+	cfg, err := config.LoadWithOptions(opts)
 	if err != nil {
 		slog.Error("failed to load configuration", "error", err)
 		os.Exit(1)
@@ -44,12 +91,43 @@ func main() {
 
 	slog.Info("configuration loaded",
 		"server_addr", cfg.GetServerAddr(),
-		"environment", os.Getenv("ENV"))
+		"environment", cfg.Environment(),
+		"config_file", cfg.ConfigFile(),
+		"database", cfg.RedactedDatabaseURL())
+
+	database, err := db.Open(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("database connected", "driver", cfg.Database.Driver, "max_open_conns", cfg.Database.MaxOpenConns)
 
-	sessionManager := session.NewManager(cfg)
+	if cfg.Database.MigrateOnStart {
+		migrator, err := db.NewMigrator(database, cfg, db.Migrations())
+		if err == nil {
+			_, err = migrator.Up(context.Background())
+		}
+		if err != nil {
+			slog.Error("failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	sessionStore, err := session.NewStoreFromConfig(cfg)
+	if err != nil {
+		slog.Error("failed to create session store", "error", err)
+		os.Exit(1)
+	}
+	sessionManager := session.NewManager(cfg, sessionStore)
+
+	// Settings tagged reload:"true" can be changed with SIGHUP
+	liveConfig := config.NewLive(cfg)
+	liveConfig.Subscribe(func(cfg *config.Config) { logger.SetupLogger(cfg) })
+	liveConfig.Subscribe(sessionManager.UpdateConfig)
 
 	// Create service layer with dependencies
-	service := services.NewService(sessionManager, slog.Default())
+	store := repository.NewStore(database, cfg.Database.Driver)
+	service := services.NewService(sessionManager, store, slog.Default())
 
 	// Create handler with injected service
 	h := &handlers.Handler{
@@ -63,12 +141,16 @@ func main() {
 	mux.HandleFunc("/", h.Index)
 	mux.HandleFunc("/test", h.Test)
 	mux.HandleFunc("/health", h.Health)
+	// The counters are unauthenticated, so production does not expose them
+	if cfg.BaseEnvironment() != config.EnvProduction {
+		mux.Handle("/debug/vars", session.MetricsHandler())
+	}
 
 	// Custom 404 handler for unmatched routes
 	mux.HandleFunc("/404", h.NotFound)
 
 	// Wrap with middleware and custom 404 handler
-	handler := logger.PanicRecovery(logger.RequestLogger(custom404Handler(mux, h)))
+	handler := logger.PanicRecovery(logger.RequestLogger(sessionManager.Middleware(custom404Handler(mux, h))))
 
 	server := &http.Server{
 		Addr:         cfg.GetServerAddr(),
@@ -81,6 +163,14 @@ func main() {
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			reloadConfig(liveConfig, opts)
+		}
+	}()
+
 	// start server
 	slog.Info("starting server", "address", cfg.GetServerAddr())
 	go func() {
@@ -98,10 +188,20 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
+	// Save sessions and close the database even if in-flight requests were cut off
+	exitCode := 0
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("server forced to shutdown", "error", err)
-		os.Exit(1)
+		exitCode = 1
+	}
+
+	// Stop background session cleanup and write the session snapshot
+	sessionManager.Close()
+
+	if err := database.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
 	}
 
 	slog.Info("server exited")
+	os.Exit(exitCode)
 }
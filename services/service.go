@@ -2,10 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"seesharpsi/htmx_quickstart/logger"
+	"seesharpsi/htmx_quickstart/repository"
 	"seesharpsi/htmx_quickstart/session"
 )
 
@@ -18,6 +20,19 @@ type Service interface {
 
 	// Session operations
 	GetOrCreateSession(r *http.Request) (*session.Session, http.Cookie)
+	SaveSession(sess *session.Session) error
+	RegenerateSession(w http.ResponseWriter, r *http.Request) (*session.Session, error)
+	AddFlash(sess *session.Session, level session.FlashLevel, message string) error
+	PopFlashes(sess *session.Session) ([]session.Flash, error)
+	ListSessions(ctx context.Context, filter session.ListFilter) ([]session.Info, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeAllSessionsForUser(ctx context.Context, userID string) (int, error)
+
+	// User operations
+	CreateUser(ctx context.Context, email, name string) (*repository.User, error)
+
+	// Health operations
+	CheckHealth(ctx context.Context) error
 
 	// Business logic operations
 	ProcessUserAction(ctx context.Context, action string) (*ActionResult, error)
@@ -50,13 +65,15 @@ type ValidationResult struct {
 // service implements the Service interface
 type service struct {
 	sessionManager *session.Manager
+	store          *repository.Store
 	logger         *slog.Logger
 }
 
 // NewService creates a new service instance with dependencies
-func NewService(sessionManager *session.Manager, logger *slog.Logger) Service {
+func NewService(sessionManager *session.Manager, store *repository.Store, logger *slog.Logger) Service {
 	return &service{
 		sessionManager: sessionManager,
+		store:          store,
 		logger:         logger,
 	}
 }
@@ -170,3 +187,86 @@ func (s *service) ValidateAndProcessInput(ctx context.Context, input map[string]
 func (s *service) GetOrCreateSession(r *http.Request) (*session.Session, http.Cookie) {
 	return s.sessionManager.GetOrCreateSession(r)
 }
+
+// SaveSession persists changes made to a session's data
+func (s *service) SaveSession(sess *session.Session) error {
+	return s.sessionManager.Save(sess)
+}
+
+// RegenerateSession rotates the session ID while keeping its data. Call it on
+// login and logout so a pre-authentication session ID can't be reused
+func (s *service) RegenerateSession(w http.ResponseWriter, r *http.Request) (*session.Session, error) {
+	return s.sessionManager.Regenerate(w, r)
+}
+
+// AddFlash queues a one-off message to show on the user's next page view
+func (s *service) AddFlash(sess *session.Session, level session.FlashLevel, message string) error {
+	return s.sessionManager.AddFlash(sess, level, message)
+}
+
+// PopFlashes returns and clears the session's pending flash messages
+func (s *service) PopFlashes(sess *session.Session) ([]session.Flash, error) {
+	return s.sessionManager.PopFlashes(sess)
+}
+
+// ListSessions returns active sessions, e.g. for an admin or "active devices" page
+func (s *service) ListSessions(ctx context.Context, filter session.ListFilter) ([]session.Info, error) {
+	requestID := logger.RequestIDFromContext(ctx)
+	s.logger.Info("listing sessions", "user_id", filter.UserID, "request_id", requestID)
+
+	return s.sessionManager.List(filter)
+}
+
+// RevokeSession terminates a single session, e.g. for a lost device
+func (s *service) RevokeSession(ctx context.Context, id string) error {
+	requestID := logger.RequestIDFromContext(ctx)
+	s.logger.Info("revoking session", "request_id", requestID)
+
+	return s.sessionManager.Revoke(id)
+}
+
+// RevokeAllSessionsForUser terminates every session of a user, e.g. after an account compromise
+func (s *service) RevokeAllSessionsForUser(ctx context.Context, userID string) (int, error) {
+	requestID := logger.RequestIDFromContext(ctx)
+	revoked, err := s.sessionManager.RevokeAllForUser(userID)
+	s.logger.Info("revoked user sessions", "user_id", userID, "revoked", revoked, "request_id", requestID)
+
+	return revoked, err
+}
+
+// ErrEmailTaken is returned when creating a user whose email is already registered
+var ErrEmailTaken = errors.New("email address is already registered")
+
+// CreateUser registers a new user, checking the email is unused in the same transaction
+func (s *service) CreateUser(ctx context.Context, email, name string) (*repository.User, error) {
+	requestID := logger.RequestIDFromContext(ctx)
+	user := &repository.User{Email: email, Name: name}
+
+	err := s.store.WithTx(ctx, func(ctx context.Context) error {
+		_, err := s.store.Users.GetByEmail(ctx, email)
+		switch {
+		case err == nil:
+			return ErrEmailTaken
+		case !errors.Is(err, repository.ErrNotFound):
+			return err
+		}
+		return s.store.Users.Create(ctx, user)
+	})
+	if err != nil {
+		s.logger.Error("failed to create user", "error", err, "request_id", requestID)
+		return nil, err
+	}
+
+	s.logger.Info("created user", "user_id", user.ID, "request_id", requestID)
+	return user, nil
+}
+
+// CheckHealth reports whether the service's dependencies are reachable
+func (s *service) CheckHealth(ctx context.Context) error {
+	if err := s.store.Ping(ctx); err != nil {
+		requestID := logger.RequestIDFromContext(ctx)
+		s.logger.Error("database health check failed", "error", err, "request_id", requestID)
+		return err
+	}
+	return nil
+}
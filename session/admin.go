@@ -0,0 +1,132 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// userIDKey is the data bag key holding the ID of the user a session belongs to.
+const userIDKey = "_user_id"
+
+// Info is a read-only snapshot of a session's metadata, suitable for
+// admin pages and "active devices" lists.
+type Info struct {
+	ID           string
+	UserID       string
+	CreatedAt    time.Time
+	LastAccessed time.Time
+	UserAgent    string
+	RemoteAddr   string
+}
+
+// ListFilter narrows the sessions returned by Manager.List. Zero values match everything.
+type ListFilter struct {
+	UserID       string
+	ActiveSince  time.Time
+	CreatedAfter time.Time
+}
+
+// matches reports whether a session satisfies the filter.
+func (f ListFilter) matches(info Info) bool {
+	if f.UserID != "" && info.UserID != f.UserID {
+		return false
+	}
+	if !f.ActiveSince.IsZero() && info.LastAccessed.Before(f.ActiveSince) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !info.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	return true
+}
+
+// UserID returns the ID of the user the session belongs to, if any.
+func (s *Session) UserID() string {
+	userID, _ := s.GetString(userIDKey)
+	return userID
+}
+
+// SetUserID associates the session with a user so it can be listed and
+// revoked per user. Pair it with Manager.Regenerate on login.
+func (s *Session) SetUserID(userID string) {
+	if userID == "" {
+		s.Delete(userIDKey)
+		return
+	}
+	s.Set(userIDKey, userID)
+}
+
+// info returns a metadata snapshot of the session.
+func (s *Session) info() Info {
+	return Info{
+		ID:           s.ID,
+		UserID:       s.UserID(),
+		CreatedAt:    s.CreatedAt,
+		LastAccessed: s.LastAccessed(),
+		UserAgent:    s.UserAgent,
+		RemoteAddr:   s.RemoteAddr,
+	}
+}
+
+// List returns metadata for the live sessions matching filter, most recently
+// active first. Cookie-backed sessions are not known to the server and are never listed.
+func (m *Manager) List(filter ListFilter) ([]Info, error) {
+	now := time.Now()
+
+	var infos []Info
+	err := m.store.Iterate(func(s *Session) bool {
+		if m.isExpired(s, now) {
+			return true
+		}
+		if info := s.info(); filter.matches(info) {
+			infos = append(infos, info)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastAccessed.After(infos[j].LastAccessed)
+	})
+	return infos, nil
+}
+
+// Revoke deletes a session so its cookie no longer authenticates.
+func (m *Manager) Revoke(id string) error {
+	if m.cookies != nil {
+		return fmt.Errorf("revoke: %w by the cookie session store", errors.ErrUnsupported)
+	}
+	if err := m.store.Delete(id); err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser deletes every session belonging to userID and returns how
+// many were revoked.
+func (m *Manager) RevokeAllForUser(userID string) (int, error) {
+	if m.cookies != nil {
+		return 0, fmt.Errorf("revoke: %w by the cookie session store", errors.ErrUnsupported)
+	}
+	if userID == "" {
+		return 0, errors.New("revoke: user ID is required")
+	}
+
+	sessions, err := m.List(ListFilter{UserID: userID})
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, s := range sessions {
+		if err := m.Revoke(s.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
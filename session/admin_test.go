@@ -0,0 +1,79 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSaveDoesNotRestoreRemovedSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(t *testing.T, m *Manager, s *Session)
+	}{
+		{"revoked", func(t *testing.T, m *Manager, s *Session) {
+			if err := m.Revoke(s.ID); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"revoked for user", func(t *testing.T, m *Manager, s *Session) {
+			if n, err := m.RevokeAllForUser("u1"); err != nil || n != 1 {
+				t.Fatalf("RevokeAllForUser = %d, %v; want 1 revoked", n, err)
+			}
+		}},
+		{"swept", func(t *testing.T, m *Manager, s *Session) {
+			s.touch(time.Now().Add(-2 * m.config().Session.MaxAge))
+			if n, err := m.Sweep(); err != nil || n != 1 {
+				t.Fatalf("Sweep = %d, %v; want 1 evicted", n, err)
+			}
+		}},
+		{"evicted", func(t *testing.T, m *Manager, s *Session) {
+			m.store.(*MemoryStore).maxSessions = 1
+			m.CreateSession()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, NewMemoryStore(), false)
+			s := m.createSession(nil)
+			s.SetUserID("u1")
+			if err := m.Save(s); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.remove(t, m, s)
+
+			// A request still holding the session saves a change to it
+			if err := m.AddFlash(s, FlashInfo, "too late"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("AddFlash error = %v, want ErrNotFound", err)
+			}
+			if got := m.GetSession(s.ID); got != nil {
+				t.Error("removed session authenticates again")
+			}
+			if sessions, err := m.List(ListFilter{UserID: "u1"}); err != nil || len(sessions) != 0 {
+				t.Errorf("List = %d sessions, %v; want none", len(sessions), err)
+			}
+		})
+	}
+}
+
+func TestSaveInsertsNewSession(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(), false)
+	s := newSession()
+	s.Set("theme", "dark")
+	if err := m.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetSession(s.ID); got != s {
+		t.Errorf("GetSession = %v, want the saved session", got)
+	}
+
+	// Later saves update it in place
+	s.Set("theme", "light")
+	if err := m.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	if theme, _ := m.GetSession(s.ID).GetString("theme"); theme != "light" {
+		t.Errorf("theme = %q, want light", theme)
+	}
+}
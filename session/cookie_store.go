@@ -0,0 +1,242 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cookieChunkSize is the largest value written to a single cookie, leaving
+// room for the name and attributes within the 4KB browser limit.
+const cookieChunkSize = 3800
+
+// ErrSessionTooLarge is returned when an encoded session exceeds the cookie store's size limit.
+var ErrSessionTooLarge = errors.New("session too large for cookie store")
+
+// CookieStore keeps session state client-side in AES-GCM encrypted cookies,
+// split across several cookies when it exceeds 4KB. Nothing is kept on the
+// server, so the Store methods are no-ops; the Manager reads and writes the
+// cookies itself, which requires Manager.Middleware to be installed.
+type CookieStore struct {
+	aeads   []cipher.AEAD
+	maxSize int
+}
+
+// NewCookieStore creates a cookie store whose encryption keys are derived from
+// the session secret keys. The first key encrypts; every key is tried when
+// decrypting. maxSize limits the total encoded size in bytes.
+func NewCookieStore(secrets []string, maxSize int) (*CookieStore, error) {
+	keys := make([][]byte, 0, len(secrets))
+	for _, secret := range secrets {
+		key := sha256.Sum256([]byte("session-encryption:" + secret))
+		keys = append(keys, key[:])
+	}
+	if len(keys) == 0 {
+		slog.Warn("no session secret keys configured, using an ephemeral cookie encryption key")
+		key := make([]byte, 32)
+		rand.Read(key)
+		keys = append(keys, key)
+	}
+
+	c := &CookieStore{maxSize: maxSize}
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("creating cookie cipher: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("creating cookie cipher: %w", err)
+		}
+		c.aeads = append(c.aeads, aead)
+	}
+	return c, nil
+}
+
+// Get always reports ErrNotFound; cookie sessions are loaded from the request.
+func (c *CookieStore) Get(id string) (*Session, error) {
+	return nil, ErrNotFound
+}
+
+// Save is a no-op; cookie sessions are written to the response.
+func (c *CookieStore) Save(s *Session) error {
+	return nil
+}
+
+// Update is a no-op; cookie sessions are written to the response.
+func (c *CookieStore) Update(s *Session) error {
+	return nil
+}
+
+// Delete is a no-op; cookie sessions are revoked by overwriting the cookie.
+func (c *CookieStore) Delete(id string) error {
+	return nil
+}
+
+// Touch is a no-op; the access time is refreshed whenever the cookie is written.
+func (c *CookieStore) Touch(id string, at time.Time) error {
+	return nil
+}
+
+// Iterate is a no-op; the server has no record of cookie sessions.
+func (c *CookieStore) Iterate(fn func(s *Session) bool) error {
+	return nil
+}
+
+// encode serializes and encrypts a session into a cookie-safe string.
+func (c *CookieStore) encode(s *Session) (string, error) {
+	plaintext, err := json.Marshal(s.toRecord())
+	if err != nil {
+		return "", fmt.Errorf("encoding cookie session: %w", err)
+	}
+
+	aead := c.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+	if c.maxSize > 0 && len(value) > c.maxSize {
+		return "", fmt.Errorf("%w: %d bytes exceeds %d", ErrSessionTooLarge, len(value), c.maxSize)
+	}
+	return value, nil
+}
+
+// decode decrypts and deserializes a session, trying every key.
+func (c *CookieStore) decode(value string) (*Session, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding cookie session: %w", err)
+	}
+
+	for _, aead := range c.aeads {
+		if len(ciphertext) < aead.NonceSize() {
+			break
+		}
+		nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, sealed, nil)
+		if err != nil {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(plaintext, &rec); err != nil {
+			return nil, fmt.Errorf("decoding cookie session: %w", err)
+		}
+		return fromRecord(rec), nil
+	}
+	return nil, errors.New("cookie session failed authentication")
+}
+
+// read joins the session cookie and its numbered chunks from the request.
+func (c *CookieStore) read(r *http.Request, name string) (string, bool) {
+	first, err := r.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString(first.Value)
+	for i := 1; ; i++ {
+		chunk, err := r.Cookie(chunkName(name, i))
+		if err != nil {
+			break
+		}
+		b.WriteString(chunk.Value)
+	}
+	return b.String(), true
+}
+
+// write splits value into chunk cookies based on base and sets them on w,
+// expiring any leftover chunks from a previously larger session.
+func (c *CookieStore) write(w http.ResponseWriter, r *http.Request, base http.Cookie, value string) {
+	name := base.Name
+	chunks := 0
+	for start := 0; start < len(value) || chunks == 0; start += cookieChunkSize {
+		end := min(start+cookieChunkSize, len(value))
+		cookie := base
+		cookie.Name = chunkName(name, chunks)
+		cookie.Value = value[start:end]
+		http.SetCookie(w, &cookie)
+		chunks++
+	}
+
+	for i := chunks; ; i++ {
+		if _, err := r.Cookie(chunkName(name, i)); err != nil {
+			break
+		}
+		stale := base
+		stale.Name = chunkName(name, i)
+		stale.Value = ""
+		stale.Expires = time.Unix(0, 0)
+		stale.MaxAge = -1
+		http.SetCookie(w, &stale)
+	}
+}
+
+// chunkName returns the cookie name for the i-th chunk of a session cookie.
+func chunkName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return name + "_" + strconv.Itoa(i)
+}
+
+// getOrCreateCookieSession loads the session from the request's cookies, or
+// starts a new one, and registers it to be written by Middleware.
+func (m *Manager) getOrCreateCookieSession(r *http.Request) *Session {
+	state := requestStateFromContext(r.Context())
+	if state == nil {
+		slog.Error("cookie session store used without session middleware, changes will be lost")
+		session := newSession()
+		session.recordClient(r)
+		return session
+	}
+	if state.session != nil {
+		return state.session
+	}
+
+	if value, ok := m.cookies.read(r, m.config().GetSessionCookieName()); ok {
+		session, err := m.cookies.decode(value)
+		switch {
+		case err != nil:
+			rejectedCookies.Add(1)
+			slog.Warn("rejected invalid session cookie", "error", err, "remote_addr", r.RemoteAddr)
+		case !m.isExpired(session, time.Now()):
+			state.session = session
+			return session
+		}
+	}
+
+	state.session = newSession()
+	state.session.recordClient(r)
+	state.fresh = true
+	return state.session
+}
+
+// regenerateCookieSession replaces the request's cookie session with a copy
+// under a fresh ID. The new cookie is written by Middleware.
+func (m *Manager) regenerateCookieSession(r *http.Request) (*Session, error) {
+	state := requestStateFromContext(r.Context())
+	if state == nil {
+		return nil, errors.New("regenerate: cookie session store requires session middleware")
+	}
+
+	old := m.getOrCreateCookieSession(r)
+	session := newSession()
+	session.recordClient(r)
+	session.CreatedAt = old.CreatedAt
+	session.data = old.cloneData()
+	state.session = session
+	return session, nil
+}
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestCookieStore(t *testing.T, secrets ...string) *CookieStore {
+	t.Helper()
+	c, err := NewCookieStore(secrets, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCookieStoreRoundTrip(t *testing.T) {
+	c := newTestCookieStore(t, "key")
+
+	s := newSession()
+	s.UserAgent = "test-agent"
+	s.Set("name", "gopher")
+	s.Set("count", 3)
+
+	value, err := c.encode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.decode(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != s.ID || got.UserAgent != s.UserAgent {
+		t.Errorf("decoded session %s (%q), want %s (%q)", got.ID, got.UserAgent, s.ID, s.UserAgent)
+	}
+	if !got.CreatedAt.Equal(s.CreatedAt) || !got.LastAccessed().Equal(s.LastAccessed()) {
+		t.Errorf("decoded times %v, %v; want %v, %v", got.CreatedAt, got.LastAccessed(), s.CreatedAt, s.LastAccessed())
+	}
+	if name, _ := got.GetString("name"); name != "gopher" {
+		t.Errorf("name = %q, want gopher", name)
+	}
+	if count, _ := got.GetInt("count"); count != 3 {
+		t.Errorf("count = %d, want 3", count)
+	}
+}
+
+func TestCookieStoreDecode(t *testing.T) {
+	s := newSession()
+	value, err := newTestCookieStore(t, "old-key").encode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip one bit of the ciphertext so it still decodes but fails authentication
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)-1] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name    string
+		store   *CookieStore
+		value   string
+		wantErr bool
+	}{
+		{"same key", newTestCookieStore(t, "old-key"), value, false},
+		{"old key after rotation", newTestCookieStore(t, "new-key", "old-key"), value, false},
+		{"retired key", newTestCookieStore(t, "new-key"), value, true},
+		{"tampered", newTestCookieStore(t, "old-key"), tampered, true},
+		{"truncated", newTestCookieStore(t, "old-key"), value[:8], true},
+		{"not base64", newTestCookieStore(t, "old-key"), "not base64!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.store.decode(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("decode succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.ID != s.ID {
+				t.Errorf("decoded ID %s, want %s", got.ID, s.ID)
+			}
+		})
+	}
+}
+
+func TestCookieStoreMaxSize(t *testing.T) {
+	c, err := NewCookieStore([]string{"key"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newSession()
+	s.Set("big", strings.Repeat("x", 200))
+
+	if _, err := c.encode(s); !errors.Is(err, ErrSessionTooLarge) {
+		t.Errorf("encode error = %v, want ErrSessionTooLarge", err)
+	}
+
+	m := newTestManager(t, c, false)
+	if err := m.Save(s); !errors.Is(err, ErrSessionTooLarge) {
+		t.Errorf("Save error = %v, want ErrSessionTooLarge", err)
+	}
+	if err := m.AddFlash(s, FlashInfo, "saved"); !errors.Is(err, ErrSessionTooLarge) {
+		t.Errorf("AddFlash error = %v, want ErrSessionTooLarge", err)
+	}
+}
+
+func TestCookieStoreChunks(t *testing.T) {
+	c := newTestCookieStore(t, "key")
+
+	tests := []struct {
+		name       string
+		size       int
+		existing   int // Chunk cookies sent with the request
+		wantChunks []string
+		wantStale  []string
+	}{
+		{"small", 10, 0, []string{"session"}, nil},
+		{"exactly one chunk", cookieChunkSize, 0, []string{"session"}, nil},
+		{"just over one chunk", cookieChunkSize + 1, 0, []string{"session", "session_1"}, nil},
+		{"three chunks", 2*cookieChunkSize + 1, 0, []string{"session", "session_1", "session_2"}, nil},
+		{"shrunk to one chunk", 10, 3, []string{"session"}, []string{"session_1", "session_2"}},
+		{"shrunk to two chunks", cookieChunkSize + 1, 3, []string{"session", "session_1"}, []string{"session_2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := strings.Repeat("v", tt.size)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for i := 0; i < tt.existing; i++ {
+				r.AddCookie(&http.Cookie{Name: chunkName("session", i), Value: "old"})
+			}
+			w := httptest.NewRecorder()
+			c.write(w, r, http.Cookie{Name: "session", Path: "/"}, value)
+
+			var chunks, stale []string
+			next := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, cookie := range w.Result().Cookies() {
+				if cookie.MaxAge < 0 {
+					stale = append(stale, cookie.Name)
+					continue
+				}
+				if len(cookie.Value) > cookieChunkSize {
+					t.Errorf("cookie %s is %d bytes, over %d", cookie.Name, len(cookie.Value), cookieChunkSize)
+				}
+				chunks = append(chunks, cookie.Name)
+				next.AddCookie(cookie)
+			}
+			if strings.Join(chunks, ",") != strings.Join(tt.wantChunks, ",") {
+				t.Errorf("chunks %v, want %v", chunks, tt.wantChunks)
+			}
+			if strings.Join(stale, ",") != strings.Join(tt.wantStale, ",") {
+				t.Errorf("expired %v, want %v", stale, tt.wantStale)
+			}
+
+			got, ok := c.read(next, "session")
+			if !ok || got != value {
+				t.Errorf("read back %d bytes (ok %v), want %d", len(got), ok, len(value))
+			}
+		})
+	}
+}
+
+func TestCookieStoreReadMissing(t *testing.T) {
+	c := newTestCookieStore(t, "key")
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session_1", Value: "orphan"})
+
+	if _, ok := c.read(r, "session"); ok {
+		t.Error("read succeeded without the first chunk")
+	}
+}
@@ -0,0 +1,177 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Get returns the value stored under key.
+func (s *Session) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// Set stores a value under key and marks the session dirty.
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[string]any)
+	}
+	s.data[key] = value
+	s.dirty = true
+}
+
+// Delete removes key from the session and marks it dirty if it was present.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok {
+		delete(s.data, key)
+		s.dirty = true
+	}
+}
+
+// Keys returns the keys currently stored in the session.
+func (s *Session) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// GetString returns the string stored under key.
+func (s *Session) GetString(key string) (string, bool) {
+	v, ok := s.Get(key)
+	if !ok {
+		return "", false
+	}
+	str, ok := v.(string)
+	return str, ok
+}
+
+// GetInt returns the integer stored under key. Numbers that went through a
+// JSON round trip (float64, json.Number) are converted when they are whole.
+func (s *Session) GetInt(key string) (int, bool) {
+	v, ok := s.Get(key)
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return int(i), true
+		}
+	}
+	return 0, false
+}
+
+// GetBool returns the boolean stored under key.
+func (s *Session) GetBool(key string) (bool, bool) {
+	v, ok := s.Get(key)
+	if !ok {
+		return false, false
+	}
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// GetTime returns the time stored under key. RFC 3339 strings are parsed so
+// values survive serialization by a store.
+func (s *Session) GetTime(key string) (time.Time, bool) {
+	v, ok := s.Get(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// SetJSON encodes value as JSON and stores it under key.
+func (s *Session) SetJSON(key string, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding session value %q: %w", key, err)
+	}
+	s.Set(key, string(b))
+	return nil
+}
+
+// GetJSON decodes the value stored under key into dst. It returns false if
+// the key is not present.
+func (s *Session) GetJSON(key string, dst any) (bool, error) {
+	v, ok := s.Get(key)
+	if !ok {
+		return false, nil
+	}
+
+	var raw []byte
+	switch val := v.(type) {
+	case string:
+		raw = []byte(val)
+	case []byte:
+		raw = val
+	case json.RawMessage:
+		raw = val
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return true, fmt.Errorf("encoding session value %q: %w", key, err)
+		}
+		raw = b
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return true, fmt.Errorf("decoding session value %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// cloneData returns a shallow copy of the session's data bag.
+func (s *Session) cloneData() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data := make(map[string]any, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data
+}
+
+// Dirty reports whether the session has changed since it was last saved.
+func (s *Session) Dirty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dirty
+}
+
+// markClean clears the dirty flag after the session has been persisted.
+func (s *Session) markClean() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = false
+}
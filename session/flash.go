@@ -0,0 +1,58 @@
+package session
+
+import "fmt"
+
+// flashKey is the data bag key under which pending flash messages are stored.
+const flashKey = "_flashes"
+
+// FlashLevel describes the severity of a flash message.
+type FlashLevel string
+
+const (
+	FlashInfo    FlashLevel = "info"
+	FlashSuccess FlashLevel = "success"
+	FlashWarning FlashLevel = "warning"
+	FlashError   FlashLevel = "error"
+)
+
+// Flash is a one-off message shown to the user on their next page view.
+type Flash struct {
+	Level   FlashLevel `json:"level"`
+	Message string     `json:"message"`
+}
+
+// AddFlash queues a flash message on the session and persists it so it
+// survives a redirect.
+func (m *Manager) AddFlash(s *Session, level FlashLevel, message string) error {
+	if s == nil {
+		return fmt.Errorf("add flash: no session")
+	}
+
+	var flashes []Flash
+	if _, err := s.GetJSON(flashKey, &flashes); err != nil {
+		return err
+	}
+	flashes = append(flashes, Flash{Level: level, Message: message})
+	if err := s.SetJSON(flashKey, flashes); err != nil {
+		return err
+	}
+	return m.Save(s)
+}
+
+// PopFlashes returns all pending flash messages and removes them from the session.
+func (m *Manager) PopFlashes(s *Session) ([]Flash, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var flashes []Flash
+	found, err := s.GetJSON(flashKey, &flashes)
+	if err != nil || !found {
+		return nil, err
+	}
+	s.Delete(flashKey)
+	if err := m.Save(s); err != nil {
+		return nil, err
+	}
+	return flashes, nil
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryStore is the default Store, keeping sessions in a map in process
+// memory. When bounded, the least recently used session is evicted once the
+// store is full.
+type MemoryStore struct {
+	sessions    map[string]*list.Element
+	lru         *list.List // Front is most recently used; values are *Session
+	maxSessions int
+	mutex       sync.RWMutex
+}
+
+// NewMemoryStore creates an empty, unbounded in-memory session store.
+func NewMemoryStore() *MemoryStore {
+	return NewBoundedMemoryStore(0)
+}
+
+// NewBoundedMemoryStore creates an in-memory session store holding at most
+// maxSessions sessions. A maxSessions of 0 means unbounded.
+func NewBoundedMemoryStore(maxSessions int) *MemoryStore {
+	return &MemoryStore{
+		sessions:    make(map[string]*list.Element),
+		lru:         list.New(),
+		maxSessions: maxSessions,
+	}
+}
+
+// Get retrieves a session by its ID and marks it as recently used.
+func (s *MemoryStore) Get(id string) (*Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	elem, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*Session), nil
+}
+
+// Save stores a session under its ID.
+func (s *MemoryStore) Save(session *Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.insert(session) {
+		sessionsCreated.Add(1)
+	}
+	return nil
+}
+
+// Update replaces a stored session without re-inserting one that was deleted.
+func (s *MemoryStore) Update(session *Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	elem, ok := s.sessions[session.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	elem.Value = session
+	s.lru.MoveToFront(elem)
+	return nil
+}
+
+// Delete removes a session by its ID.
+func (s *MemoryStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if elem, ok := s.sessions[id]; ok {
+		s.remove(elem)
+	}
+	return nil
+}
+
+// Touch updates the last accessed time of a session.
+func (s *MemoryStore) Touch(id string, at time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	elem, ok := s.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	s.lru.MoveToFront(elem)
+	elem.Value.(*Session).touch(at)
+	return nil
+}
+
+// Iterate calls fn for each session until fn returns false. fn must not
+// call back into the store.
+func (s *MemoryStore) Iterate(fn func(session *Session) bool) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, elem := range s.sessions {
+		if !fn(elem.Value.(*Session)) {
+			break
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored sessions.
+func (s *MemoryStore) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.sessions)
+}
+
+// insert adds or replaces a session, evicting the least recently used
+// sessions when over capacity. It reports whether the session was new.
+// The caller must hold the write lock.
+func (s *MemoryStore) insert(session *Session) bool {
+	if elem, ok := s.sessions[session.ID]; ok {
+		elem.Value = session
+		s.lru.MoveToFront(elem)
+		return false
+	}
+
+	s.sessions[session.ID] = s.lru.PushFront(session)
+	sessionsActive.Add(1)
+
+	for s.maxSessions > 0 && len(s.sessions) > s.maxSessions {
+		s.remove(s.lru.Back())
+		sessionsEvicted.Add(1)
+	}
+	return true
+}
+
+// remove deletes a list element and its map entry. The caller must hold the write lock.
+func (s *MemoryStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.sessions, elem.Value.(*Session).ID)
+	sessionsActive.Add(-1)
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+// counterDeltas returns the change in the created, evicted and active
+// counters since the given starting values.
+func counterDeltas(created, evicted, active int64) [3]int64 {
+	return [3]int64{
+		sessionsCreated.Value() - created,
+		sessionsEvicted.Value() - evicted,
+		sessionsActive.Value() - active,
+	}
+}
+
+func TestMemoryStoreLRUEviction(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int
+		saves       int
+		touch       []int // Indexes read before the last save, marking them recently used
+		wantKept    []int
+		wantCounter [3]int64 // created, evicted, active
+	}{
+		{"unbounded", 0, 4, nil, []int{0, 1, 2, 3}, [3]int64{4, 0, 4}},
+		{"below cap", 5, 4, nil, []int{0, 1, 2, 3}, [3]int64{4, 0, 4}},
+		{"at cap", 4, 4, nil, []int{0, 1, 2, 3}, [3]int64{4, 0, 4}},
+		{"evicts oldest", 2, 4, nil, []int{2, 3}, [3]int64{4, 2, 2}},
+		{"reads refresh", 3, 4, []int{0}, []int{0, 2, 3}, [3]int64{4, 1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, evicted, active := sessionsCreated.Value(), sessionsEvicted.Value(), sessionsActive.Value()
+			store := NewBoundedMemoryStore(tt.max)
+			t.Cleanup(func() {
+				for _, s := range store.sessions {
+					store.Delete(s.Value.(*Session).ID)
+				}
+			})
+
+			sessions := make([]*Session, tt.saves)
+			for i := range sessions {
+				if i == tt.saves-1 {
+					for _, idx := range tt.touch {
+						if _, err := store.Get(sessions[idx].ID); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+				sessions[i] = newSession()
+				if err := store.Save(sessions[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kept := make(map[int]bool)
+			for _, idx := range tt.wantKept {
+				kept[idx] = true
+			}
+			for i, s := range sessions {
+				_, err := store.Get(s.ID)
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					t.Fatal(err)
+				}
+				if (err == nil) != kept[i] {
+					t.Errorf("session %d stored = %v, want %v", i, err == nil, kept[i])
+				}
+			}
+			if store.Len() != len(tt.wantKept) {
+				t.Errorf("Len() = %d, want %d", store.Len(), len(tt.wantKept))
+			}
+			if got := counterDeltas(created, evicted, active); got != tt.wantCounter {
+				t.Errorf("counters (created, evicted, active) grew by %v, want %v", got, tt.wantCounter)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreCounters(t *testing.T) {
+	created, evicted, active := sessionsCreated.Value(), sessionsEvicted.Value(), sessionsActive.Value()
+	store := NewMemoryStore()
+
+	s := newSession()
+	store.Save(s)
+	store.Save(s) // Replacing a session does not count as a new one
+	if err := store.Update(s); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := counterDeltas(created, evicted, active), [3]int64{1, 0, 1}; got != want {
+		t.Errorf("after saves: counters grew by %v, want %v", got, want)
+	}
+
+	store.Delete(s.ID)
+	store.Delete(s.ID) // Deleting a missing session changes nothing
+	if got, want := counterDeltas(created, evicted, active), [3]int64{1, 0, 0}; got != want {
+		t.Errorf("after delete: counters grew by %v, want %v", got, want)
+	}
+	if err := store.Update(s); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update after delete = %v, want ErrNotFound", err)
+	}
+}
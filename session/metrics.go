@@ -0,0 +1,37 @@
+package session
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// Session counters, served as JSON by MetricsHandler. They are kept out of
+// the global expvar registry so that serving them never exposes the process
+// command line or memory statistics.
+var (
+	sessionsCreated = new(expvar.Int)
+	sessionsEvicted = new(expvar.Int)
+	sessionsExpired = new(expvar.Int)
+	sessionsActive  = new(expvar.Int)
+
+	// rejectedCookies counts session cookies that failed signature verification or decryption.
+	rejectedCookies = new(expvar.Int)
+
+	metrics = new(expvar.Map)
+)
+
+func init() {
+	metrics.Set("sessions_created", sessionsCreated)
+	metrics.Set("sessions_evicted", sessionsEvicted)
+	metrics.Set("sessions_expired", sessionsExpired)
+	metrics.Set("sessions_active", sessionsActive)
+	metrics.Set("session_cookies_rejected", rejectedCookies)
+}
+
+// MetricsHandler serves the session counters as a JSON object.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(metrics.String()))
+	})
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type contextKey string
+
+const requestStateKey contextKey = "sessionRequestState"
+
+// requestState tracks the session used during a request so its cookie can
+// be written before the response headers are sent.
+type requestState struct {
+	request *http.Request
+	session *Session
+	fresh   bool // The session was created during this request and has no cookie yet
+}
+
+// Middleware tracks the session resolved during each request and writes
+// session cookies to the response just before the headers are sent. It must
+// wrap every handler that uses sessions when the cookie store or lazy
+// creation is active. Changes saved after the response has started are not
+// sent to the client.
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &requestState{}
+		r = r.WithContext(context.WithValue(r.Context(), requestStateKey, state))
+		state.request = r
+
+		sw := &sessionWriter{ResponseWriter: w, manager: m, state: state}
+		next.ServeHTTP(sw, r)
+		sw.writeSession()
+	})
+}
+
+// requestStateFromContext returns the per-request session state installed by Middleware.
+func requestStateFromContext(ctx context.Context) *requestState {
+	state, _ := ctx.Value(requestStateKey).(*requestState)
+	return state
+}
+
+// sessionWriter wraps http.ResponseWriter to emit session cookies before the
+// first byte of the response is written.
+type sessionWriter struct {
+	http.ResponseWriter
+	manager *Manager
+	state   *requestState
+	written bool
+}
+
+func (sw *sessionWriter) WriteHeader(code int) {
+	sw.writeSession()
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *sessionWriter) Write(b []byte) (int, error) {
+	sw.writeSession()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// writeSession emits the request's session cookies once.
+func (sw *sessionWriter) writeSession() {
+	if sw.written || sw.state.session == nil {
+		return
+	}
+	sw.written = true
+
+	session := sw.state.session
+	if sw.manager.cookies == nil {
+		// Stored sessions have their cookies issued by GetOrCreateSession or
+		// Regenerate; a lazily created one is stored, and gets a cookie, once
+		// something is written to it
+		if !sw.state.fresh {
+			return
+		}
+		if err := sw.manager.Save(session); err != nil {
+			slog.Error("failed to save session", "error", err)
+			return
+		}
+		if _, err := sw.manager.store.Get(session.ID); err == nil {
+			cookie := sw.manager.newCookie(session)
+			http.SetCookie(sw.ResponseWriter, &cookie)
+		}
+		return
+	}
+
+	if sw.manager.config().Session.LazyCreate && sw.state.fresh && len(session.Keys()) == 0 {
+		return
+	}
+
+	session.touch(time.Now())
+	value, err := sw.manager.cookies.encode(session)
+	if err != nil {
+		slog.Error("failed to write cookie session", "error", err)
+		return
+	}
+	sw.manager.cookies.write(sw.ResponseWriter, sw.state.request, sw.manager.newCookie(session), value)
+	session.markClean()
+}
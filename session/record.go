@@ -0,0 +1,42 @@
+package session
+
+import "time"
+
+// record is the serialized form of a session, shared by the cookie store and
+// memory store snapshots.
+type record struct {
+	ID           string         `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	LastAccessed time.Time      `json:"last_accessed"`
+	UserAgent    string         `json:"user_agent,omitempty"`
+	RemoteAddr   string         `json:"remote_addr,omitempty"`
+	Data         map[string]any `json:"data,omitempty"`
+}
+
+// toRecord returns the serializable form of the session.
+func (s *Session) toRecord() record {
+	return record{
+		ID:           s.ID,
+		CreatedAt:    s.CreatedAt,
+		LastAccessed: s.LastAccessed(),
+		UserAgent:    s.UserAgent,
+		RemoteAddr:   s.RemoteAddr,
+		Data:         s.cloneData(),
+	}
+}
+
+// fromRecord rebuilds a session from its serialized form.
+func fromRecord(rec record) *Session {
+	if rec.Data == nil {
+		rec.Data = make(map[string]any)
+	}
+	return &Session{
+		ID:           rec.ID,
+		CreatedAt:    rec.CreatedAt,
+		lastAccessed: rec.LastAccessed,
+		UserAgent:    rec.UserAgent,
+		RemoteAddr:   rec.RemoteAddr,
+		data:         rec.Data,
+		stored:       true,
+	}
+}
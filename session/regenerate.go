@@ -0,0 +1,48 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Regenerate moves the request's session data to a fresh session ID, deletes
+// the old session and re-issues the cookie on w. It must be called whenever a
+// user's privilege level changes (login, logout, role change) to prevent
+// session fixation. The old session is the one already resolved during the
+// request, if any, otherwise the one named by the request's cookie. If there
+// is none, a new empty one is issued.
+func (m *Manager) Regenerate(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	if m.cookies != nil {
+		return m.regenerateCookieSession(r)
+	}
+
+	old := m.currentSession(r)
+
+	// The absolute lifetime still counts from the original session's creation
+	session := newSession()
+	session.recordClient(r)
+	if old != nil {
+		session.CreatedAt = old.CreatedAt
+		session.data = old.cloneData()
+	}
+
+	if err := m.insert(session); err != nil {
+		return nil, fmt.Errorf("saving regenerated session: %w", err)
+	}
+	if old != nil {
+		if err := m.store.Delete(old.ID); err != nil {
+			return nil, fmt.Errorf("deleting old session: %w", err)
+		}
+	}
+
+	// Later lookups in this request see the new session, and Middleware
+	// leaves its cookie to the one set here
+	if state := requestStateFromContext(r.Context()); state != nil {
+		state.session = session
+		state.fresh = false
+	}
+
+	cookie := m.newCookie(session)
+	http.SetCookie(w, &cookie)
+	return session, nil
+}
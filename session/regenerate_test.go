@@ -0,0 +1,159 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+// newTestManager creates a manager over store with the development defaults,
+// without a snapshot file or sweeper.
+func newTestManager(t *testing.T, store Store, lazy bool) *Manager {
+	t.Helper()
+	cfg, err := config.Defaults(config.EnvDevelopment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Session.SnapshotFile = ""
+	cfg.Session.CleanupInterval = 0
+	cfg.Session.LazyCreate = lazy
+	m := NewManager(cfg, store)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// sessionCookies returns the values of every session cookie set on the response.
+func sessionCookies(m *Manager, rec *httptest.ResponseRecorder) []string {
+	var values []string
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == m.config().GetSessionCookieName() {
+			values = append(values, c.Value)
+		}
+	}
+	return values
+}
+
+func TestRegenerateSessionCreatedInRequest(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(), false)
+
+	var old, regenerated *Session
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cookie http.Cookie
+		old, cookie = m.GetOrCreateSession(r)
+		http.SetCookie(w, &cookie)
+		old.Set("cart", "apples")
+		if err := m.Save(old); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		if regenerated, err = m.Regenerate(w, r); err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := m.GetOrCreateSession(r); again != regenerated {
+			t.Errorf("GetOrCreateSession after Regenerate returned %s, want %s", again.ID, regenerated.ID)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if regenerated.ID == old.ID {
+		t.Fatal("Regenerate kept the session ID")
+	}
+	if cart, _ := regenerated.GetString("cart"); cart != "apples" {
+		t.Errorf("cart = %q, want apples", cart)
+	}
+	if _, err := m.store.Get(old.ID); err == nil {
+		t.Error("old session is still stored")
+	}
+	if got := m.store.(*MemoryStore).Len(); got != 1 {
+		t.Errorf("store holds %d sessions, want 1", got)
+	}
+
+	cookies := sessionCookies(m, rec)
+	if len(cookies) == 0 {
+		t.Fatal("no session cookie set")
+	}
+	if id, _ := m.signer.verify(m.config().GetSessionCookieName(), cookies[len(cookies)-1]); id != regenerated.ID {
+		t.Errorf("last session cookie names %s, want %s", id, regenerated.ID)
+	}
+}
+
+func TestRegenerateLazySession(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(), true)
+
+	var old, regenerated *Session
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cookie http.Cookie
+		old, cookie = m.GetOrCreateSession(r)
+		http.SetCookie(w, &cookie)
+		if err := m.AddFlash(old, FlashInfo, "welcome"); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		if regenerated, err = m.Regenerate(w, r); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, err := m.store.Get(old.ID); err == nil {
+		t.Error("old session is still stored")
+	}
+	if _, err := m.store.Get(regenerated.ID); err != nil {
+		t.Errorf("regenerated session not stored: %v", err)
+	}
+	if keys := regenerated.Keys(); len(keys) != 1 || keys[0] != flashKey {
+		t.Errorf("regenerated session keys = %v, want [%s]", keys, flashKey)
+	}
+
+	cookies := sessionCookies(m, rec)
+	if len(cookies) != 1 {
+		t.Fatalf("set %d session cookies, want 1", len(cookies))
+	}
+	if id, _ := m.signer.verify(m.config().GetSessionCookieName(), cookies[0]); id != regenerated.ID {
+		t.Errorf("session cookie names %s, want %s", id, regenerated.ID)
+	}
+}
+
+func TestLazySessionStoredWhenWritten(t *testing.T) {
+	tests := []struct {
+		name       string
+		write      bool
+		wantCookie bool
+	}{
+		{"untouched", false, false},
+		{"set without save", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, NewMemoryStore(), true)
+
+			var sess *Session
+			handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sess, _ = m.GetOrCreateSession(r)
+				if tt.write {
+					sess.Set("theme", "dark")
+				}
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			_, err := m.store.Get(sess.ID)
+			if stored := err == nil; stored != tt.wantCookie {
+				t.Errorf("stored = %v, want %v", stored, tt.wantCookie)
+			}
+			if got := len(sessionCookies(m, rec)); (got > 0) != tt.wantCookie {
+				t.Errorf("set %d session cookies, want cookie = %v", got, tt.wantCookie)
+			}
+		})
+	}
+}
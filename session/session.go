@@ -3,85 +3,296 @@ package session
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"seesharpsi/htmx_quickstart/config"
 )
 
+// maxUserAgentLength caps the user agent recorded on a session.
+const maxUserAgentLength = 256
+
 // Session holds the state for a single user's story.
 type Session struct {
-	ID           string
-	LastAccessed time.Time
+	ID         string
+	CreatedAt  time.Time
+	UserAgent  string
+	RemoteAddr string
+
+	mu           sync.RWMutex
+	data         map[string]any
+	dirty        bool
+	stored       bool // The session has been saved to the store at least once
+	lastAccessed time.Time
 }
 
 // Manager handles the creation, storage, and retrieval of sessions.
 type Manager struct {
-	sessions map[string]*Session
-	mutex    sync.Mutex
-	config   *config.Config
+	store   Store
+	cookies *CookieStore // set when sessions live in encrypted cookies
+	cfg     atomic.Pointer[config.Config]
+	signer  *signer
+
+	stopSweeper chan struct{}
+	sweeperDone chan struct{}
+	closeOnce   sync.Once
 }
 
-// NewManager creates a new session manager with configuration.
-func NewManager(cfg *config.Config) *Manager {
-	return &Manager{
-		sessions: make(map[string]*Session),
-		config:   cfg,
+// NewManager creates a new session manager backed by the given store and
+// starts the expiry sweeper. A nil store falls back to an in-memory store,
+// which is restored from SnapshotFile when configured. Call Close to stop the
+// sweeper and write the snapshot.
+func NewManager(cfg *config.Config, store Store) *Manager {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	m := &Manager{
+		store:       store,
+		signer:      newSigner(config.RevealAll(cfg.Session.SecretKeys)),
+		stopSweeper: make(chan struct{}),
+		sweeperDone: make(chan struct{}),
+	}
+	m.cfg.Store(cfg)
+	if cookies, ok := store.(*CookieStore); ok {
+		m.cookies = cookies
 	}
+	m.restoreSnapshot()
+	m.startSweeper()
+	return m
+}
+
+// config returns the current configuration.
+func (m *Manager) config() *config.Config {
+	return m.cfg.Load()
+}
+
+// UpdateConfig swaps in a reloaded configuration. Cookie attributes and
+// timeouts take effect on the next request; the store, secret keys, sweeper
+// interval and snapshot file keep the values the manager was created with.
+func (m *Manager) UpdateConfig(cfg *config.Config) {
+	m.cfg.Store(cfg)
+}
+
+// Store returns the backing session store.
+func (m *Manager) Store() Store {
+	return m.store
 }
 
 // CreateSession creates a new session and returns its ID.
 func (m *Manager) CreateSession() string {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	return m.createSession(nil).ID
+}
 
-	// Generate a random, secure session ID.
-	b := make([]byte, 16)
-	rand.Read(b)
-	id := hex.EncodeToString(b)
+// createSession creates and stores a new session, recording client metadata
+// from r when it is not nil.
+func (m *Manager) createSession(r *http.Request) *Session {
+	session := newSession()
+	session.recordClient(r)
+	if err := m.insert(session); err != nil {
+		slog.Error("failed to save session", "error", err)
+	}
+	return session
+}
 
-	m.sessions[id] = &Session{
-		ID: id,
-		//GameState:    &story.GameState{},
-		//StoryHistory: []story.StoryPage{},
-		LastAccessed: time.Now(),
+// insert adds a new session to the store.
+func (m *Manager) insert(s *Session) error {
+	if err := m.store.Save(s); err != nil {
+		return err
 	}
-	return id
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stored = true
+	return nil
 }
 
 // GetSession retrieves a session by its ID.
 func (m *Manager) GetSession(id string) *Session {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	session, ok := m.sessions[id]
-	if !ok {
+	session, err := m.store.Get(id)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.Error("failed to load session", "error", err)
+		}
+		return nil
+	}
+	if m.isExpired(session, time.Now()) {
+		if err := m.store.Delete(id); err != nil {
+			slog.Error("failed to delete expired session", "error", err)
+		}
 		return nil
 	}
-	session.LastAccessed = time.Now()
+	if err := m.store.Touch(id, time.Now()); err != nil {
+		slog.Error("failed to touch session", "error", err)
+	}
 	return session
 }
 
+// Save persists a session if it has changed since it was loaded. A session
+// revoked, expired or evicted while in use is not brought back; Save returns
+// ErrNotFound instead. Cookie sessions are written by Middleware, so Save
+// only checks that the session still fits, returning ErrSessionTooLarge otherwise.
+func (m *Manager) Save(s *Session) error {
+	if s == nil || !s.Dirty() {
+		return nil
+	}
+	if m.cookies != nil {
+		_, err := m.cookies.encode(s)
+		return err
+	}
+	s.mu.RLock()
+	stored := s.stored
+	s.mu.RUnlock()
+
+	save := m.store.Update
+	if !stored {
+		save = m.insert
+	}
+	if err := save(s); err != nil {
+		return err
+	}
+	s.markClean()
+	return nil
+}
+
 // GetOrCreateSession retrieves an existing session or creates a new one.
+// Within Middleware, later calls in the same request return the same session.
 func (m *Manager) GetOrCreateSession(r *http.Request) (*Session, http.Cookie) {
-	cookie, err := r.Cookie(m.config.Session.CookieName)
-	if err == nil {
-		session := m.GetSession(cookie.Value)
+	if m.cookies != nil {
+		return m.getOrCreateCookieSession(r), http.Cookie{}
+	}
+
+	state := requestStateFromContext(r.Context())
+	if state != nil && state.session != nil {
+		if state.fresh {
+			return state.session, http.Cookie{}
+		}
+		return state.session, m.newCookie(state.session)
+	}
+
+	if id, ok := m.sessionIDFromRequest(r); ok {
+		session := m.GetSession(id)
 		if session != nil {
-			return session, *cookie
+			if state != nil {
+				state.session = session
+			}
+			// Re-issue the cookie so its expiry slides with the idle timeout
+			return session, m.newCookie(session)
 		}
 	}
 
+	// With lazy creation the new session is only stored, and its cookie only
+	// issued by Middleware, once something is written to it
+	if m.config().Session.LazyCreate && state != nil {
+		state.session = newSession()
+		state.session.recordClient(r)
+		state.fresh = true
+		return state.session, http.Cookie{}
+	}
+
 	// If no valid session is found, create a new one.
-	id := m.CreateSession()
-	newCookie := http.Cookie{
-		Name:     m.config.Session.CookieName,
-		Value:    id,
-		Expires:  time.Now().Add(m.config.Session.MaxAge),
-		HttpOnly: m.config.Session.HttpOnly,
-		Secure:   m.config.Session.Secure,
-		Path:     "/",
-		SameSite: http.SameSiteLaxMode, // Default to Lax for now
-	}
-	return m.GetSession(id), newCookie
+	session := m.createSession(r)
+	if state != nil {
+		state.session = session
+	}
+	return session, m.newCookie(session)
+}
+
+// currentSession returns the session already resolved for the request, or
+// the one named by its cookie.
+func (m *Manager) currentSession(r *http.Request) *Session {
+	if state := requestStateFromContext(r.Context()); state != nil && state.session != nil {
+		return state.session
+	}
+	if id, ok := m.sessionIDFromRequest(r); ok {
+		return m.GetSession(id)
+	}
+	return nil
+}
+
+// sessionIDFromRequest returns the session ID carried by the request's
+// cookie. Unsigned or tampered cookies are rejected before any store lookup.
+func (m *Manager) sessionIDFromRequest(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(m.config().GetSessionCookieName())
+	if err != nil {
+		return "", false
+	}
+	id, ok := m.signer.verify(cookie.Name, cookie.Value)
+	if !ok {
+		rejectedCookies.Add(1)
+		slog.Warn("rejected session cookie with invalid signature", "remote_addr", r.RemoteAddr)
+		return "", false
+	}
+	return id, true
+}
+
+// newCookie builds the session cookie for a session, expiring when the
+// session would next time out.
+func (m *Manager) newCookie(s *Session) http.Cookie {
+	path := m.config().Session.Path
+	if path == "" {
+		path = "/"
+	}
+	name := m.config().GetSessionCookieName()
+	cookie := http.Cookie{
+		Name:     name,
+		Value:    m.signer.sign(name, s.ID),
+		HttpOnly: m.config().Session.HttpOnly,
+		Secure:   m.config().Session.Secure,
+		Domain:   m.config().Session.Domain,
+		Path:     path,
+		SameSite: m.config().GetSessionSameSite(),
+	}
+	if expires := m.expiresAt(s, time.Now()); !expires.IsZero() {
+		cookie.Expires = expires
+		cookie.MaxAge = max(int(time.Until(expires).Seconds()), 1)
+	}
+	return cookie
+}
+
+// newSession creates an empty session with a fresh ID.
+func newSession() *Session {
+	now := time.Now()
+	return &Session{
+		ID:           newSessionID(),
+		CreatedAt:    now,
+		lastAccessed: now,
+		data:         make(map[string]any),
+	}
+}
+
+// LastAccessed returns when the session was last used.
+func (s *Session) LastAccessed() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastAccessed
+}
+
+// touch records that the session was used at the given time. Concurrent
+// requests sharing a cookie touch the same session, so it is guarded by mu.
+func (s *Session) touch(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAccessed = at
+}
+
+// recordClient stores metadata about the client that created the session.
+func (s *Session) recordClient(r *http.Request) {
+	if r == nil {
+		return
+	}
+	s.UserAgent = r.UserAgent()
+	if len(s.UserAgent) > maxUserAgentLength {
+		s.UserAgent = s.UserAgent[:maxUserAgentLength]
+	}
+	s.RemoteAddr = r.RemoteAddr
+}
+
+// newSessionID generates a random, secure session ID.
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
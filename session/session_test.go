@@ -0,0 +1,71 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionCookieSlides(t *testing.T) {
+	tests := []struct {
+		name       string
+		age        time.Duration // Since the session was created
+		wantMaxAge time.Duration
+	}{
+		{"slides with idle timeout", time.Hour, time.Hour},
+		{"capped at absolute deadline", 23*time.Hour + 30*time.Minute, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, NewMemoryStore(), false)
+			m.config().Session.MaxAge = time.Hour
+			m.config().Session.AbsoluteTimeout = 24 * time.Hour
+
+			s := m.createSession(nil)
+			s.CreatedAt = time.Now().Add(-tt.age)
+			s.touch(time.Now().Add(-30 * time.Minute))
+			first := m.newCookie(s)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&first)
+			got, cookie := m.GetOrCreateSession(r)
+			if got != s {
+				t.Fatalf("GetOrCreateSession returned session %s, want %s", got.ID, s.ID)
+			}
+			if since := time.Since(s.LastAccessed()); since > time.Minute {
+				t.Errorf("last accessed %v ago, want it refreshed", since)
+			}
+
+			if cookie.Value != first.Value {
+				t.Errorf("cookie value changed on re-issue")
+			}
+			want := time.Now().Add(tt.wantMaxAge)
+			if diff := cookie.Expires.Sub(want).Abs(); diff > 2*time.Second {
+				t.Errorf("cookie expires %v, want about %v", cookie.Expires, want)
+			}
+			if diff := time.Duration(cookie.MaxAge)*time.Second - tt.wantMaxAge; diff.Abs() > 2*time.Second {
+				t.Errorf("cookie Max-Age = %ds, want about %v", cookie.MaxAge, tt.wantMaxAge)
+			}
+		})
+	}
+}
+
+func TestExpiredSessionReplaced(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(), false)
+	m.config().Session.MaxAge = time.Hour
+
+	s := m.createSession(nil)
+	s.touch(time.Now().Add(-2 * time.Hour))
+	cookie := m.newCookie(s)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&cookie)
+	got, _ := m.GetOrCreateSession(r)
+	if got.ID == s.ID {
+		t.Fatal("expired session was reused")
+	}
+	if _, err := m.store.Get(s.ID); err == nil {
+		t.Error("expired session is still stored")
+	}
+}
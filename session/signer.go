@@ -0,0 +1,59 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+)
+
+// signer signs and verifies cookie values with HMAC-SHA256. The first key
+// signs new values; every key is accepted when verifying so keys can be rotated.
+type signer struct {
+	keys [][]byte
+}
+
+// newSigner creates a signer from the configured secret keys. When no keys
+// are configured an ephemeral random key is used, which invalidates all
+// cookies on restart.
+func newSigner(secrets []string) *signer {
+	s := &signer{}
+	for _, secret := range secrets {
+		s.keys = append(s.keys, []byte(secret))
+	}
+	if len(s.keys) == 0 {
+		slog.Warn("no session secret keys configured, using an ephemeral key")
+		key := make([]byte, 32)
+		rand.Read(key)
+		s.keys = append(s.keys, key)
+	}
+	return s
+}
+
+// sign returns value with its signature appended.
+func (s *signer) sign(name, value string) string {
+	return value + "." + s.mac(s.keys[0], name, value)
+}
+
+// verify checks a signed value against every key and returns the original value.
+func (s *signer) verify(name, signed string) (string, bool) {
+	value, sig, ok := strings.Cut(signed, ".")
+	if !ok || value == "" {
+		return "", false
+	}
+	for _, key := range s.keys {
+		if hmac.Equal([]byte(sig), []byte(s.mac(key, name, value))) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// mac computes the signature of a cookie value bound to the cookie name.
+func (s *signer) mac(key []byte, name, value string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "=" + value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,54 @@
+package session
+
+import "testing"
+
+func TestSignerVerify(t *testing.T) {
+	current := newSigner([]string{"current-key"})
+	old := newSigner([]string{"old-key"})
+	rotated := newSigner([]string{"current-key", "old-key"})
+
+	signed := current.sign("session_id", "abc123")
+	signedByOld := old.sign("session_id", "abc123")
+
+	// Flip one character of the signature
+	sig := []byte(signed)
+	sig[len(sig)-1] ^= 1
+	tamperedSig := string(sig)
+
+	tests := []struct {
+		name     string
+		verifier *signer
+		cookie   string
+		signed   string
+		want     string
+		ok       bool
+	}{
+		{"valid", current, "session_id", signed, "abc123", true},
+		{"tampered value", current, "session_id", "abc124" + signed[len("abc123"):], "", false},
+		{"tampered signature", current, "session_id", tamperedSig, "", false},
+		{"unsigned", current, "session_id", "abc123", "", false},
+		{"empty value", current, "session_id", signed[len("abc123"):], "", false},
+		{"empty", current, "session_id", "", "", false},
+		{"other cookie name", current, "other", signed, "", false},
+		{"old key after rotation", rotated, "session_id", signedByOld, "abc123", true},
+		{"new key after rotation", rotated, "session_id", signed, "abc123", true},
+		{"retired key", current, "session_id", signedByOld, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.verifier.verify(tt.cookie, tt.signed)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("verify(%q, %q) = %q, %v; want %q, %v", tt.cookie, tt.signed, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSignerSignsWithFirstKey(t *testing.T) {
+	rotated := newSigner([]string{"current-key", "old-key"})
+	current := newSigner([]string{"current-key"})
+
+	if got, want := rotated.sign("session_id", "abc123"), current.sign("session_id", "abc123"); got != want {
+		t.Errorf("sign with rotated keys = %q, want %q", got, want)
+	}
+}
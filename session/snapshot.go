@@ -0,0 +1,129 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// WriteSnapshot atomically writes every session to path as JSON, least
+// recently used first, and returns the number of sessions written. The file
+// is only readable by its owner.
+func (s *MemoryStore) WriteSnapshot(path string) (int, error) {
+	s.mutex.RLock()
+	records := make([]record, 0, len(s.sessions))
+	for elem := s.lru.Back(); elem != nil; elem = elem.Prev() {
+		records = append(records, elem.Value.(*Session).toRecord())
+	}
+	s.mutex.RUnlock()
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		return 0, fmt.Errorf("encoding session snapshot: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, fmt.Errorf("creating session snapshot directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it so a crash never leaves a partial snapshot
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("creating session snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("writing session snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("writing session snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("writing session snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("replacing session snapshot: %w", err)
+	}
+	return len(records), nil
+}
+
+// ReadSnapshot loads sessions from a snapshot written by WriteSnapshot,
+// skipping those for which expired returns true, and returns the number
+// loaded. Sessions are inserted oldest access first, so the LRU order
+// survives the restart. A missing file is not an error.
+func (s *MemoryStore) ReadSnapshot(path string, expired func(*Session) bool) (int, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading session snapshot: %w", err)
+	}
+
+	var records []record
+	if err := json.Unmarshal(b, &records); err != nil {
+		return 0, fmt.Errorf("decoding session snapshot: %w", err)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].LastAccessed.Before(records[j].LastAccessed)
+	})
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	loaded := 0
+	for _, rec := range records {
+		session := fromRecord(rec)
+		if expired != nil && expired(session) {
+			continue
+		}
+		s.insert(session)
+		loaded++
+	}
+	return loaded, nil
+}
+
+// restoreSnapshot reloads the in-memory store from the configured snapshot
+// file, dropping sessions that expired while the server was down.
+func (m *Manager) restoreSnapshot() {
+	path := m.config().Session.SnapshotFile
+	memory, ok := m.store.(*MemoryStore)
+	if path == "" || !ok {
+		return
+	}
+
+	now := time.Now()
+	loaded, err := memory.ReadSnapshot(path, func(s *Session) bool {
+		return m.isExpired(s, now)
+	})
+	if err != nil {
+		slog.Error("failed to restore session snapshot", "error", err, "path", path)
+		return
+	}
+	slog.Info("restored session snapshot", "path", path, "sessions", loaded)
+}
+
+// saveSnapshot writes the in-memory store to the configured snapshot file.
+func (m *Manager) saveSnapshot() {
+	path := m.config().Session.SnapshotFile
+	memory, ok := m.store.(*MemoryStore)
+	if path == "" || !ok {
+		return
+	}
+
+	saved, err := memory.WriteSnapshot(path)
+	if err != nil {
+		slog.Error("failed to save session snapshot", "error", err, "path", path)
+		return
+	}
+	slog.Info("saved session snapshot", "path", path, "sessions", saved)
+}
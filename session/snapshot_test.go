@@ -0,0 +1,60 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotPreservesLRUOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	base := time.Now()
+
+	src := NewMemoryStore()
+	ids := make([]string, 4)
+	for i := range ids {
+		s := newSession()
+		ids[i] = s.ID
+		if err := src.Save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Access order, oldest first: 2, 0, 3, 1
+	for i, idx := range []int{2, 0, 3, 1} {
+		if err := src.Touch(ids[idx], base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := src.WriteSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		max  int
+		want []string // Most recently used first
+	}{
+		{"unbounded", 0, []string{ids[1], ids[3], ids[0], ids[2]}},
+		{"evicts least recently used", 2, []string{ids[1], ids[3]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewBoundedMemoryStore(tt.max)
+			if _, err := dst.ReadSnapshot(path, nil); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for elem := dst.lru.Front(); elem != nil; elem = elem.Next() {
+				got = append(got, elem.Value.(*Session).ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("restored %d sessions, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("position %d: got %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"seesharpsi/htmx_quickstart/config"
+)
+
+// ErrNotFound is returned by a Store when no session exists for an ID.
+var ErrNotFound = errors.New("session not found")
+
+// Store persists sessions on behalf of a Manager. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Get returns the session with the given ID, or ErrNotFound.
+	Get(id string) (*Session, error)
+	// Save inserts or replaces a session.
+	Save(s *Session) error
+	// Update replaces a stored session, or returns ErrNotFound if it has
+	// been deleted, so a revoked or expired session is never brought back.
+	Update(s *Session) error
+	// Delete removes a session. Deleting a missing session is not an error.
+	Delete(id string) error
+	// Touch updates the last accessed time of a session, or returns ErrNotFound.
+	Touch(id string, at time.Time) error
+	// Iterate calls fn for every stored session until fn returns false.
+	Iterate(fn func(s *Session) bool) error
+}
+
+// NewStoreFromConfig creates the session store selected by SessionConfig.Store.
+func NewStoreFromConfig(cfg *config.Config) (Store, error) {
+	switch cfg.Session.Store {
+	case "", "memory":
+		return NewBoundedMemoryStore(cfg.Session.MaxSessions), nil
+	case "cookie":
+		return NewCookieStore(config.RevealAll(cfg.Session.SecretKeys), cfg.Session.CookieMaxSize)
+	default:
+		return nil, fmt.Errorf("unknown session store '%s'", cfg.Session.Store)
+	}
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"log/slog"
+	"time"
+)
+
+// startSweeper launches the background goroutine that evicts expired
+// sessions every CleanupInterval. It is a no-op when the interval is not positive.
+func (m *Manager) startSweeper() {
+	interval := m.config().Session.CleanupInterval
+	if interval <= 0 {
+		close(m.sweeperDone)
+		return
+	}
+
+	go func() {
+		defer close(m.sweeperDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				evicted, err := m.Sweep()
+				if err != nil {
+					slog.Error("session sweep failed", "error", err, "evicted", evicted)
+					continue
+				}
+				slog.Info("session sweep completed", "evicted", evicted)
+			case <-m.stopSweeper:
+				return
+			}
+		}
+	}()
+}
+
+// Sweep removes every session that has passed its idle or absolute timeout
+// and returns the number of sessions evicted.
+func (m *Manager) Sweep() (int, error) {
+	now := time.Now()
+
+	var expired []string
+	err := m.store.Iterate(func(s *Session) bool {
+		if m.isExpired(s, now) {
+			expired = append(expired, s.ID)
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	evicted := 0
+	for _, id := range expired {
+		if err := m.store.Delete(id); err != nil {
+			return evicted, err
+		}
+		sessionsExpired.Add(1)
+		evicted++
+	}
+	return evicted, nil
+}
+
+// isExpired reports whether a session has been idle for longer than MaxAge
+// or has outlived AbsoluteTimeout since it was created.
+func (m *Manager) isExpired(s *Session, now time.Time) bool {
+	idle := m.config().Session.MaxAge
+	if idle > 0 && now.Sub(s.LastAccessed()) > idle {
+		return true
+	}
+	absolute := m.config().Session.AbsoluteTimeout
+	return absolute > 0 && now.Sub(s.CreatedAt) > absolute
+}
+
+// expiresAt returns when a session accessed at now will time out, whichever
+// of the idle and absolute limits comes first. It returns the zero time when
+// neither limit is set.
+func (m *Manager) expiresAt(s *Session, now time.Time) time.Time {
+	var expires time.Time
+	if idle := m.config().Session.MaxAge; idle > 0 {
+		expires = now.Add(idle)
+	}
+	if absolute := m.config().Session.AbsoluteTimeout; absolute > 0 {
+		if deadline := s.CreatedAt.Add(absolute); expires.IsZero() || deadline.Before(expires) {
+			expires = deadline
+		}
+	}
+	return expires
+}
+
+// Close stops the background sweeper, waits for it to exit and snapshots
+// in-memory sessions when SnapshotFile is configured.
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stopSweeper)
+		<-m.sweeperDone
+		m.saveSnapshot()
+	})
+}
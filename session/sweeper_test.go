@@ -0,0 +1,136 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSweep(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(), false)
+	m.config().Session.MaxAge = time.Hour
+	m.config().Session.AbsoluteTimeout = 0
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		lastAccessed time.Time
+		wantKept     bool
+	}{
+		{"just used", now, true},
+		{"idle within max age", now.Add(-59 * time.Minute), true},
+		{"idle past max age", now.Add(-61 * time.Minute), false},
+		{"idle for days", now.Add(-72 * time.Hour), false},
+	}
+	ids := make([]string, len(tests))
+	for i, tt := range tests {
+		s := m.createSession(nil)
+		s.touch(tt.lastAccessed)
+		ids[i] = s.ID
+	}
+
+	expiredBefore := sessionsExpired.Value()
+	evicted, err := m.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evicted != 2 {
+		t.Errorf("Sweep evicted %d sessions, want 2", evicted)
+	}
+	if got := sessionsExpired.Value() - expiredBefore; got != 2 {
+		t.Errorf("sessions_expired grew by %d, want 2", got)
+	}
+	for i, tt := range tests {
+		_, err := m.store.Get(ids[i])
+		if kept := err == nil; kept != tt.wantKept {
+			t.Errorf("%s: kept = %v, want %v", tt.name, kept, tt.wantKept)
+		}
+	}
+}
+
+func TestSweeperRunsInBackground(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(), false)
+	m.config().Session.MaxAge = time.Hour
+	s := m.createSession(nil)
+	s.touch(time.Now().Add(-2 * time.Hour))
+
+	// newTestManager disables the sweeper, so start one with a short interval
+	m.config().Session.CleanupInterval = 10 * time.Millisecond
+	m.stopSweeper = make(chan struct{})
+	m.sweeperDone = make(chan struct{})
+	m.startSweeper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.store.(*MemoryStore).Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("sweeper did not evict the expired session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		maxAge       time.Duration
+		absolute     time.Duration
+		created      time.Time
+		lastAccessed time.Time
+		wantExpired  bool
+		wantExpires  time.Time // For a request at now
+	}{
+		{
+			name: "idle limit first", maxAge: time.Hour, absolute: 24 * time.Hour,
+			created: now.Add(-time.Hour), lastAccessed: now.Add(-time.Minute),
+			wantExpires: now.Add(time.Hour),
+		},
+		{
+			name: "absolute limit first", maxAge: time.Hour, absolute: 24 * time.Hour,
+			created: now.Add(-23*time.Hour - 30*time.Minute), lastAccessed: now.Add(-time.Minute),
+			wantExpires: now.Add(30 * time.Minute),
+		},
+		{
+			name: "idle too long", maxAge: time.Hour, absolute: 24 * time.Hour,
+			created: now.Add(-2 * time.Hour), lastAccessed: now.Add(-61 * time.Minute),
+			wantExpired: true, wantExpires: now.Add(time.Hour),
+		},
+		{
+			name: "active but past absolute", maxAge: time.Hour, absolute: 24 * time.Hour,
+			created: now.Add(-25 * time.Hour), lastAccessed: now.Add(-time.Second),
+			wantExpired: true, wantExpires: now.Add(-time.Hour),
+		},
+		{
+			name: "absolute disabled", maxAge: time.Hour,
+			created: now.Add(-365 * 24 * time.Hour), lastAccessed: now.Add(-time.Minute),
+			wantExpires: now.Add(time.Hour),
+		},
+		{
+			name: "idle disabled", absolute: 24 * time.Hour,
+			created: now.Add(-time.Hour), lastAccessed: now.Add(-20 * time.Hour),
+			wantExpires: now.Add(23 * time.Hour),
+		},
+		{
+			name:    "no limits",
+			created: now.Add(-365 * 24 * time.Hour), lastAccessed: now.Add(-365 * 24 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, NewMemoryStore(), false)
+			m.config().Session.MaxAge = tt.maxAge
+			m.config().Session.AbsoluteTimeout = tt.absolute
+
+			s := newSession()
+			s.CreatedAt = tt.created
+			s.touch(tt.lastAccessed)
+
+			if got := m.isExpired(s, now); got != tt.wantExpired {
+				t.Errorf("isExpired = %v, want %v", got, tt.wantExpired)
+			}
+			if got := m.expiresAt(s, now); !got.Equal(tt.wantExpires) {
+				t.Errorf("expiresAt = %v, want %v", got, tt.wantExpires)
+			}
+		})
+	}
+}